@@ -1,7 +1,10 @@
 package breaker
 
 import (
+	"sync/atomic"
 	"time"
+
+	"github.com/bunnier/circuit/breaker/internal"
 )
 
 // Breaker 是熔断器接口。
@@ -20,6 +23,9 @@ type Breaker interface {
 	// Timeout 用于记录失败事件。
 	Timeout()
 
+	// Latency 记录一次功能函数执行耗时。
+	Latency(d time.Duration)
+
 	// FallbackSuccess 记录一次降级函数执行成功事件。
 	FallbackSuccess()
 
@@ -28,28 +34,155 @@ type Breaker interface {
 
 	// Summary 返回当前熔断器状态信息。
 	Summary() *BreakerSummary
+
+	// Drain 返回当前熔断器状态信息与上一次Drain以来的统计数据，并同时重置这部分统计数据，用于按周期上报不重叠的统计数据。
+	// 上报的统计数据与熔断判断使用的滑动窗口分开记录，按周期Drain不会影响熔断器的判断。
+	Drain() *BreakerSummary
+
+	// LatencyBuckets 返回滑动窗口中按时间分布的耗时直方图，第一维为统计区间（从旧到新），第二维与 LatencyBucketBounds 对应。
+	LatencyBuckets() [][]int64
+
+	// Reset 用于立即将熔断器恢复为初始状态，并清空统计数据，不影响手动强制状态。
+	Reset()
+
+	// ForceOpen 用于手动强制开启熔断器，强制期间拒绝所有请求。
+	ForceOpen()
+
+	// ForceClose 用于手动强制关闭熔断器，强制期间放行所有请求。
+	ForceClose()
+
+	// ClearForced 用于清除手动强制状态，恢复熔断器的自动判断。
+	ClearForced()
+
+	// State 返回熔断器当前状态，便于程序判断，文字描述请使用 Allow 或 Summary。
+	State() State
+}
+
+// BreakerConfig 是熔断器的有效配置，用于调试或在管理界面中展示。
+type BreakerConfig struct {
+	Type    string                 // 熔断器类型，如cut、sre。
+	Options map[string]interface{} // 各项配置，key为配置名称。
+}
+
+// Configurable 是可以返回自身有效配置的熔断器，内置的熔断器都实现了该接口。
+type Configurable interface {
+	Config() BreakerConfig
+}
+
+// StateNotifier 是可以在内部状态切换时通知监听函数的熔断器，Command 通过它输出状态变化的日志与事件，而不是在执行前后比较状态。
+type StateNotifier interface {
+	// NotifyStateChange 注册一个状态变化的监听函数，每次内部状态切换成功时调用一次。
+	// 监听函数在独立的goroutine中按状态变化的先后顺序执行，不会阻塞调用方。
+	NotifyStateChange(listener func(name string, from, to State))
+}
+
+// Releaser 是放行请求时会占用资源（如并发数）的熔断器，资源通常在 Latency 中归还。
+// 放行后没有执行就被拒绝的请求（如 Command 的并发数已满）不会记录耗时，需要通过 Release 归还。
+type Releaser interface {
+	// Release 用于归还一个已放行但没有执行的请求占用的资源，不记录耗时。
+	Release()
+}
+
+// SuccessLatencyRecorder 是需要知道成功执行耗时的熔断器（如统计慢调用的 CutBreaker）。
+// Latency 与执行结果分开记录，并发时无法对应到同一次执行，所以由调用方在记录成功时再传入本次执行的耗时。
+type SuccessLatencyRecorder interface {
+	// SuccessLatency 记录一次成功执行的耗时，需要与 Success 配合使用，不代替 Latency。
+	SuccessLatency(d time.Duration)
+}
+
+// Clock 是熔断器统计数据使用的时间源，默认使用系统时间，测试时可以替换为可控的实现。
+type Clock = internal.Clock
+
+// MetricSummary 是熔断器内部统计数据的摘要，用于自定义熔断算法的输入（如 WithSreBreakerAcceptsFunc）。
+type MetricSummary = internal.MetricSummary
+
+// LatencyBucketBounds 返回耗时直方图各个桶的上限（含），最后还有一个没有上限的溢出桶。
+func LatencyBucketBounds() []time.Duration {
+	return internal.LatencyBucketBounds()
 }
 
 // BreakerSummary 返回统计数据摘要。
 type BreakerSummary struct {
+	Name   string // 名称。
 	Status string // 熔断器当前状态的文字描述。
 
+	WarmedUp bool // 窗口内的请求数量是否已经满足熔断器做出判断的最小要求，没有最小要求的熔断器有请求即为true。
+
 	TimeWindowSecond     int64 // 滑动窗口的大小。
 	MetricIntervalSecond int64 // 窗口中每个统计量的间隔区间。
 
 	Success         int64 // 成功数量。
 	Timeout         int64 // 超时数量。
-	Failure         int64 // 失败数量。
+	Failure         int64 // 失败数量，已经包含了超时数量，即 Failure = 非超时的失败数量 + Timeout。
 	FallbackSuccess int64 // 降级函数执行成功数量。
 	FallbackFailure int64 // 降级函数执行失败数量。
 
-	Total           int64   // 本次统计窗口所执行的所有次数。
-	ErrorPercentage float64 // 错误数量百分比。
+	// 本次统计窗口功能函数的执行结果总数，即 Success + Failure，超时只计算一次，不包含降级函数的执行结果。
+	RequestTotal    int64
+	Total           int64   // 与 RequestTotal 相同，为兼容保留。
+	ErrorPercentage float64 // 错误数量百分比，即 Failure / RequestTotal，超时也计入其中。
+
+	SlowCall           int64   // 慢调用数量，需要熔断器设置了慢调用标准。
+	SlowCallPercentage float64 // 慢调用百分比，即 SlowCall / RequestTotal。
+
+	MeanLatency time.Duration // 功能函数平均耗时。
+	P99Latency  time.Duration // 功能函数99分位耗时（按直方图桶的上限估算）。
 
 	LastExecuteTime time.Time // 最后一次执行时间。
 	LastSuccessTime time.Time // 最后一次成功执行时间。
 	LastTimeoutTime time.Time // 最后一次超时时间。
 	LastFailureTime time.Time // 最后一次失败时间。
+
+	SampleTime time.Time // 生成摘要的时间，用于 Delta 计算两次摘要的间隔。
+
+	TopErrorKeys []ErrorKeyCount // 出现次数最多的错误特征，按次数降序，需要在Command中设置错误特征函数。
+
+	// 以下为自创建以来的累计数量，只增不减，不受滑动窗口、Reset 与 Drain 的影响，Delta 通过它们计算变化量。
+	CumulativeSuccess         int64 // 累计成功数量。
+	CumulativeTimeout         int64 // 累计超时数量。
+	CumulativeFailure         int64 // 累计失败数量，已经包含了超时数量。
+	CumulativeFallbackSuccess int64 // 累计降级函数执行成功数量。
+	CumulativeFallbackFailure int64 // 累计降级函数执行失败数量。
+}
+
+// ErrorKeyCount 记录一种错误特征及其出现次数。
+type ErrorKeyCount struct {
+	Key   string // 错误特征。
+	Count int64  // 出现次数。
+}
+
+// newBreakerSummary 根据统计数据摘要生成熔断器状态信息，minRequests 为熔断器做出判断所需的最小请求数量。
+func newBreakerSummary(name string, status string, summary *internal.MetricSummary, minRequests int64) *BreakerSummary {
+	return &BreakerSummary{
+		Name:                 name,
+		Status:               status,
+		WarmedUp:             summary.Total > 0 && summary.Total >= minRequests,
+		TimeWindowSecond:     summary.TimeWindowSecond,
+		MetricIntervalSecond: summary.MetricIntervalSecond,
+		Success:              summary.Success,
+		Timeout:              summary.Timeout,
+		Failure:              summary.Failure,
+		FallbackSuccess:      summary.FallbackSuccess,
+		FallbackFailure:      summary.FallbackFailure,
+		RequestTotal:         summary.RequestTotal,
+		Total:                summary.Total,
+		ErrorPercentage:      summary.ErrorPercentage,
+		SlowCall:             summary.SlowCall,
+		SlowCallPercentage:   summary.SlowCallPercentage,
+		MeanLatency:          summary.MeanLatency,
+		P99Latency:           summary.P99Latency,
+		LastExecuteTime:      summary.LastExecuteTime,
+		LastSuccessTime:      summary.LastSuccessTime,
+		LastTimeoutTime:      summary.LastTimeoutTime,
+		LastFailureTime:      summary.LastFailureTime,
+		SampleTime:           summary.SampleTime,
+
+		CumulativeSuccess:         summary.CumulativeSuccess,
+		CumulativeTimeout:         summary.CumulativeTimeout,
+		CumulativeFailure:         summary.CumulativeFailure,
+		CumulativeFallbackSuccess: summary.CumulativeFallbackSuccess,
+		CumulativeFallbackFailure: summary.CumulativeFallbackFailure,
+	}
 }
 
 // 定义熔断器的通用状态数字表示常量。
@@ -59,3 +192,55 @@ const (
 	Openning    int32 = 1 // 熔断开启。
 	HalfOpening int32 = 2 // 半熔断状态。
 )
+
+// State 是熔断器状态的类型化表示。
+type State int32
+
+// 定义熔断器状态常量，前3个与通用状态数字表示常量一一对应。
+const (
+	StateClosed   State = State(Closed)      // 熔断关闭。
+	StateOpen     State = State(Openning)    // 熔断开启。
+	StateHalfOpen State = State(HalfOpening) // 半熔断状态。
+	StateForced   State = 3                  // 处于手动强制状态。
+)
+
+// String 返回状态的文字描述。
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	case StateForced:
+		return "forced"
+	default:
+		return "unknown"
+	}
+}
+
+// 定义熔断器的手动强制状态常量。
+const (
+	forcedNone   int32 = 0 // 未强制，按熔断器自身逻辑判断。
+	forcedOpen   int32 = 1 // 强制开启。
+	forcedClosed int32 = 2 // 强制关闭。
+)
+
+// isForced 用于判断熔断器是否处于手动强制状态。
+func isForced(forcedStatus *int32) bool {
+	return atomic.LoadInt32(forcedStatus) != forcedNone
+}
+
+// forcedAllow 用于根据手动强制状态判断是否允许通过请求。
+// 第三返回值：true表示当前处于强制状态，前两个返回值有效；false表示未强制，需按熔断器自身逻辑判断。
+func forcedAllow(forcedStatus *int32) (bool, string, bool) {
+	switch atomic.LoadInt32(forcedStatus) {
+	case forcedOpen:
+		return false, "forced-open", true
+	case forcedClosed:
+		return true, "forced-closed", true
+	default:
+		return false, "", false
+	}
+}
@@ -0,0 +1,22 @@
+package breaker
+
+import "testing"
+
+func TestState_String(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		state State
+		want  string
+	}{
+		{StateClosed, "closed"},
+		{StateOpen, "open"},
+		{StateHalfOpen, "half-open"},
+		{StateForced, "forced"},
+		{State(100), "unknown"},
+	}
+	for _, tt := range tests {
+		if got := tt.state.String(); got != tt.want {
+			t.Errorf("State.String() got = %v, want %v", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package breaker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/bunnier/circuit/breaker/internal"
+)
+
+var _ Breaker = (*consecutiveBreaker)(nil)
+var _ Configurable = (*consecutiveBreaker)(nil)
+
+// consecutiveBreaker 是 Breaker 的一种实现。
+type consecutiveBreaker struct {
+	ctx context.Context // 用于释放资源的context。
+
+	name   string           // 名称。
+	metric *internal.Metric // 执行情况统计数据。
+
+	internalStatus      int32 // 熔断器的内部状态，内部维护3个状态。
+	forcedStatus        int32 // 手动强制状态，优先于内部状态。
+	consecutiveFailures int64 // 当前连续失败（含超时）的次数。
+	openedAt            int64 // 最后一次开启的时间（UnixNano），休眠时间从此刻起算。
+
+	consecutiveThreshold int64         // 开启熔断的连续失败次数阈值。
+	sleepWindow          time.Duration // 熔断后重置熔断器的时间窗口。
+	timeWindow           time.Duration // 滑动窗口的大小（仅用于统计展示）。
+	clock                Clock         // 休眠与统计数据使用的时间源（可选）。
+}
+
+// NewConsecutiveBreaker 用于新建一个 ConsecutiveBreaker 熔断器。
+// ConsecutiveBreaker 在连续失败（含超时）达到阈值后开启，与请求量无关，任意一次成功都会将连续失败计数清零。
+// 恢复算法与 CutBreaker 相同：开启后休眠指定时间进入半开状态，只允许一个请求进入尝试，通过就关闭，不通过重新开启。
+// 连续失败次数阈值不大于0时将panic。
+func NewConsecutiveBreaker(name string, options ...ConsecutiveBreakerOption) *consecutiveBreaker {
+	b := &consecutiveBreaker{
+		ctx:                  context.Background(),
+		name:                 name,
+		internalStatus:       Closed, // 默认关闭。
+		consecutiveThreshold: 5,      // 默认连续5次失败开启。
+		sleepWindow:          time.Second * 5,
+		timeWindow:           time.Second * 5,
+	}
+
+	for _, option := range options {
+		option(b)
+	}
+	if b.consecutiveThreshold <= 0 { // 配置错误属于无法恢复的错误，与 CutBreaker 一致直接panic。
+		panic("breaker: consecutiveThreshold invalid")
+	}
+
+	// 初始化选项后，根据选项初始化Metric。
+	metricOptions := []internal.MerticOption{
+		internal.WithMetricTimeWindow(b.timeWindow),
+	}
+	if b.clock != nil {
+		metricOptions = append(metricOptions, internal.WithMetricClock(b.clock))
+	}
+	b.metric = internal.NewMetric(metricOptions...)
+
+	return b
+}
+
+// Allow 用于判断断路器是否允许通过请求。
+// 第一返回值：true能通过/false不能；第二返回值：当前Breaker状态的文字描述。
+func (b *consecutiveBreaker) Allow() (bool, string) {
+	return b.allow()
+}
+
+// allow 用于判断断路器是否允许通过请求。
+// 第一返回值：true能通过/false不能；第二返回值：当前Breaker状态的文字描述。
+func (b *consecutiveBreaker) allow() (bool, string) {
+	if pass, statusMsg, forced := forcedAllow(&b.forcedStatus); forced {
+		return pass, statusMsg // 手动强制状态优先于自动判断。
+	}
+
+	switch atomic.LoadInt32(&b.internalStatus) {
+	case Closed:
+		// 连续失败次数未达到阈值。
+		if atomic.LoadInt64(&b.consecutiveFailures) < b.consecutiveThreshold {
+			return true, "closed"
+		}
+		b.transit(Closed, Openning)
+		return false, "open" // 无论上面结果如何，都开启。
+
+	case HalfOpening:
+		return false, "half-open" // 半开状态，说明已经有一个请求正在尝试，拒绝所有其它请求。
+
+	case Openning:
+		// 判断是否已过休眠时间，从开启时刻起算，不受开启期间降级等其它统计事件的影响。
+		if b.now().Sub(time.Unix(0, atomic.LoadInt64(&b.openedAt))) < b.sleepWindow {
+			return false, "open"
+		}
+		// 过了休眠时间，设置为半开状态，并放一个请求试试，换不到的还是开启。
+		return b.transit(Openning, HalfOpening), "half-open"
+
+	default:
+		panic("breaker: impossible status")
+	}
+}
+
+// transit 用于通过CAS切换熔断器内部状态，切换到开启状态时记录开启时间。
+// 开启时间在切换前记录，并发的 allow 看到开启状态时，开启时间一定属于本次开启。
+func (b *consecutiveBreaker) transit(from, to int32) bool {
+	if to == Openning {
+		if atomic.LoadInt32(&b.internalStatus) != from { // 不会切换成功，不能改写其它轮次的开启时间。
+			return false
+		}
+		atomic.StoreInt64(&b.openedAt, b.now().UnixNano()) // 并发切换时落选的一方也会记录，休眠时间只会稍晚结束。
+	}
+	return atomic.CompareAndSwapInt32(&b.internalStatus, from, to)
+}
+
+// now 返回当前时间，没有设置时间源时使用系统时间。
+func (b *consecutiveBreaker) now() time.Time {
+	if b.clock != nil {
+		return b.clock.Now()
+	}
+	return time.Now()
+}
+
+// Success 用于记录成功事件。
+func (b *consecutiveBreaker) Success() {
+	atomic.StoreInt64(&b.consecutiveFailures, 0) // 任意一次成功都会打断连续失败。
+	b.transit(HalfOpening, Closed)
+	b.metric.Success()
+}
+
+// Failure 用于记录失败事件。
+func (b *consecutiveBreaker) Failure() {
+	atomic.AddInt64(&b.consecutiveFailures, 1)
+	b.transit(HalfOpening, Openning)
+	b.metric.Failure()
+}
+
+// Timeout 用于记录超时事件，超时也算作一次连续失败。
+func (b *consecutiveBreaker) Timeout() {
+	atomic.AddInt64(&b.consecutiveFailures, 1)
+	b.transit(HalfOpening, Openning)
+	b.metric.Timeout()
+}
+
+// Latency 记录一次功能函数执行耗时。
+func (b *consecutiveBreaker) Latency(d time.Duration) {
+	b.metric.Latency(d)
+}
+
+// FallbackSuccess 记录一次降级函数执行成功事件。
+func (b *consecutiveBreaker) FallbackSuccess() {
+	b.metric.FallbackSuccess()
+}
+
+// FallbackFailure 记录一次降级函数执行失败事件。
+func (b *consecutiveBreaker) FallbackFailure() {
+	b.metric.FallbackFailure()
+}
+
+// Summary 返回当前健康状态。
+func (b *consecutiveBreaker) Summary() *BreakerSummary {
+	return newBreakerSummary(b.name, b.status(), b.metric.Summary(), 1)
+}
+
+// Drain 返回当前健康状态，并同时重置上报用的统计数据。
+func (b *consecutiveBreaker) Drain() *BreakerSummary {
+	return newBreakerSummary(b.name, b.status(), b.metric.Drain(), 1)
+}
+
+// status 返回当前状态的文字描述，只读取状态，不会像 allow 一样切换状态，状态切换只在 Allow 与记录结果时发生。
+func (b *consecutiveBreaker) status() string {
+	if _, statusMsg, forced := forcedAllow(&b.forcedStatus); forced {
+		return statusMsg
+	}
+	return State(atomic.LoadInt32(&b.internalStatus)).String()
+}
+
+// LatencyBuckets 返回滑动窗口中按时间分布的耗时直方图。
+func (b *consecutiveBreaker) LatencyBuckets() [][]int64 {
+	return b.metric.LatencyBuckets()
+}
+
+// State 返回熔断器当前状态。
+func (b *consecutiveBreaker) State() State {
+	if isForced(&b.forcedStatus) {
+		return StateForced
+	}
+	return State(atomic.LoadInt32(&b.internalStatus))
+}
+
+// Reset 用于立即关闭熔断器，清零连续失败次数，并清空统计数据。
+func (b *consecutiveBreaker) Reset() {
+	b.metric.Reset()
+	atomic.StoreInt64(&b.consecutiveFailures, 0)
+	atomic.StoreInt32(&b.internalStatus, Closed)
+}
+
+// Config 返回熔断器的有效配置。
+func (b *consecutiveBreaker) Config() BreakerConfig {
+	return BreakerConfig{
+		Type: "consecutive",
+		Options: map[string]interface{}{
+			"timeWindow":           b.timeWindow,
+			"consecutiveThreshold": b.consecutiveThreshold,
+			"sleepWindow":          b.sleepWindow,
+		},
+	}
+}
+
+// ForceOpen 用于手动强制开启熔断器，强制期间拒绝所有请求。
+func (b *consecutiveBreaker) ForceOpen() {
+	atomic.StoreInt32(&b.forcedStatus, forcedOpen)
+}
+
+// ForceClose 用于手动强制关闭熔断器，强制期间放行所有请求。
+func (b *consecutiveBreaker) ForceClose() {
+	atomic.StoreInt32(&b.forcedStatus, forcedClosed)
+}
+
+// ClearForced 用于清除手动强制状态，恢复熔断器的自动判断。
+func (b *consecutiveBreaker) ClearForced() {
+	atomic.StoreInt32(&b.forcedStatus, forcedNone)
+}
+
+// ConsecutiveBreakerOption 是 ConsecutiveBreaker 的可选项。
+type ConsecutiveBreakerOption func(b *consecutiveBreaker)
+
+// WithConsecutiveThreshold 设置开启熔断的连续失败次数阈值（要求大于0）。
+func WithConsecutiveThreshold(n int64) ConsecutiveBreakerOption {
+	return func(b *consecutiveBreaker) {
+		b.consecutiveThreshold = n
+	}
+}
+
+// WithConsecutiveBreakerSleepWindow 设置熔断后重置熔断器的时间窗口。
+func WithConsecutiveBreakerSleepWindow(sleepWindow time.Duration) ConsecutiveBreakerOption {
+	return func(b *consecutiveBreaker) {
+		b.sleepWindow = sleepWindow
+	}
+}
+
+// WithConsecutiveBreakerTimeWindow 设置统计数据滑动窗口的大小（要求1-60s），仅影响统计展示，不影响熔断判断。
+func WithConsecutiveBreakerTimeWindow(timeWindow time.Duration) ConsecutiveBreakerOption {
+	return func(b *consecutiveBreaker) {
+		b.timeWindow = timeWindow
+	}
+}
+
+// WithConsecutiveBreakerClock 设置休眠与统计数据使用的时间源（默认系统时间），用于在测试中驱动时间。
+func WithConsecutiveBreakerClock(clock Clock) ConsecutiveBreakerOption {
+	return func(b *consecutiveBreaker) {
+		b.clock = clock
+	}
+}
+
+// WithConsecutiveBreakerContext 设置用于释放资源的context。
+func WithConsecutiveBreakerContext(ctx context.Context) ConsecutiveBreakerOption {
+	return func(b *consecutiveBreaker) {
+		b.ctx = ctx
+	}
+}
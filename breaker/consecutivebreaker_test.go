@@ -0,0 +1,205 @@
+package breaker
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestConsecutiveBreaker_resetOnSuccess 测试任意一次成功都会将连续失败计数清零。
+func TestConsecutiveBreaker_resetOnSuccess(t *testing.T) {
+	t.Parallel()
+	breaker := NewConsecutiveBreaker("test",
+		WithConsecutiveThreshold(3),
+		WithConsecutiveBreakerSleepWindow(time.Second))
+
+	breaker.Failure()
+	breaker.Timeout()
+	breaker.Success() // 连续失败被打断。
+	breaker.Failure()
+	breaker.Failure()
+
+	// 虽然累计失败了4次，但连续失败只有2次，此时应还是关闭。
+	if pass, statusMsg := breaker.Allow(); !pass {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v, want %v", pass, true)
+	} else if statusMsg != "closed" {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v, want %v", statusMsg, "closed")
+	}
+
+	breaker.Timeout()
+	// 连续失败达到3次，此时应该开启了。
+	if pass, statusMsg := breaker.Allow(); pass {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v, want %v", pass, false)
+	} else if statusMsg != "open" {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v, want %v", statusMsg, "open")
+	}
+}
+
+// TestConsecutiveBreaker_halfOpen 测试半开状态只允许一个请求进入尝试。
+func TestConsecutiveBreaker_halfOpen(t *testing.T) {
+	t.Parallel()
+	breaker := NewConsecutiveBreaker("test",
+		WithConsecutiveThreshold(3),
+		WithConsecutiveBreakerSleepWindow(500*time.Millisecond))
+
+	for i := 0; i < 3; i++ {
+		breaker.Failure()
+	}
+	if pass, _ := breaker.Allow(); pass {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v, want %v", pass, false)
+	}
+
+	time.Sleep(600 * time.Millisecond)
+
+	// 睡眠期结束，并发请求中只能有一个进入半开尝试。
+	var passCount int64
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			if pass, _ := breaker.Allow(); pass {
+				atomic.AddInt64(&passCount, 1)
+			}
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+	if passCount != 1 {
+		t.Errorf("ConsecutiveBreaker.Allow() pass count = %v, want %v", passCount, 1)
+	}
+
+	breaker.Failure()                 // 半开状态失败，再次进入熔断。
+	time.Sleep(10 * time.Millisecond) // 确保统计数据已记录完成，休眠时间从此刻起算。
+	if pass, statusMsg := breaker.Allow(); pass {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v, want %v", pass, false)
+	} else if statusMsg != "open" {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v, want %v", statusMsg, "open")
+	}
+
+	time.Sleep(600 * time.Millisecond)
+	if pass, statusMsg := breaker.Allow(); !pass {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v, want %v", pass, true)
+	} else if statusMsg != "half-open" {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v, want %v", statusMsg, "half-open")
+	}
+
+	breaker.Success() // 半开状态成功，关闭熔断器。
+	if pass, statusMsg := breaker.Allow(); !pass {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v, want %v", pass, true)
+	} else if statusMsg != "closed" {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v, want %v", statusMsg, "closed")
+	}
+}
+
+// TestConsecutiveBreaker_summaryReadOnly 测试读取统计数据不会切换状态，休眠时间过后依然由真正的请求进入半开尝试。
+func TestConsecutiveBreaker_summaryReadOnly(t *testing.T) {
+	t.Parallel()
+	breaker := NewConsecutiveBreaker("test",
+		WithConsecutiveThreshold(1),
+		WithConsecutiveBreakerSleepWindow(50*time.Millisecond))
+
+	breaker.Failure()
+	if pass, _ := breaker.Allow(); pass {
+		t.Fatalf("ConsecutiveBreaker.Allow() got = %v, want %v", pass, false)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if status := breaker.Summary().Status; status != "open" {
+			t.Errorf("ConsecutiveBreaker.Summary() Status got = %v, want %v", status, "open")
+		}
+	}
+	if status := breaker.Drain().Status; status != "open" {
+		t.Errorf("ConsecutiveBreaker.Drain() Status got = %v, want %v", status, "open")
+	}
+
+	if pass, statusMsg := breaker.Allow(); !pass || statusMsg != "half-open" {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v/%v, want %v/%v", pass, statusMsg, true, "half-open")
+	}
+}
+
+// TestConsecutiveBreaker_sleepWindowFromOpen 测试休眠时间从开启时刻起算，开启期间持续的降级事件不会推迟半开尝试。
+func TestConsecutiveBreaker_sleepWindowFromOpen(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := NewConsecutiveBreaker("test",
+		WithConsecutiveThreshold(1),
+		WithConsecutiveBreakerSleepWindow(2*time.Second),
+		WithConsecutiveBreakerClock(clock))
+
+	breaker.Failure()
+	if pass, _ := breaker.Allow(); pass {
+		t.Fatalf("ConsecutiveBreaker.Allow() got = %v, want %v", pass, false)
+	}
+
+	// 开启期间请求持续走降级逻辑。
+	for i := 0; i < 4; i++ {
+		clock.Advance(500 * time.Millisecond)
+		breaker.FallbackSuccess()
+		breaker.FallbackFailure()
+	}
+	if pass, statusMsg := breaker.Allow(); !pass || statusMsg != "half-open" {
+		t.Fatalf("ConsecutiveBreaker.Allow() got = %v/%v, want %v/%v", pass, statusMsg, true, "half-open")
+	}
+
+	// 半开尝试失败，从此刻重新起算休眠时间。
+	breaker.Failure()
+	clock.Advance(time.Second)
+	if pass, statusMsg := breaker.Allow(); pass || statusMsg != "open" {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v/%v, want %v/%v", pass, statusMsg, false, "open")
+	}
+	clock.Advance(time.Second)
+	if pass, statusMsg := breaker.Allow(); !pass || statusMsg != "half-open" {
+		t.Errorf("ConsecutiveBreaker.Allow() got = %v/%v, want %v/%v", pass, statusMsg, true, "half-open")
+	}
+}
+
+// TestNewConsecutiveBreaker_invalidThreshold 测试连续失败次数阈值不大于0时panic。
+func TestNewConsecutiveBreaker_invalidThreshold(t *testing.T) {
+	t.Parallel()
+	for _, n := range []int64{0, -1} {
+		func() {
+			defer func() {
+				if got := recover(); got != "breaker: consecutiveThreshold invalid" {
+					t.Errorf("NewConsecutiveBreaker(%d) panic got = %v, want %v", n, got, "breaker: consecutiveThreshold invalid")
+				}
+			}()
+			NewConsecutiveBreaker("test", WithConsecutiveThreshold(n))
+		}()
+	}
+}
+
+// TestConsecutiveBreaker_concurrentTrip 测试并发判断时熔断器开启，休眠时间结束前不会放行任何请求（需要 -race 运行）。
+func TestConsecutiveBreaker_concurrentTrip(t *testing.T) {
+	t.Parallel()
+	for round := 0; round < 50; round++ {
+		clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)} // 时间不推进，休眠时间不会结束。
+		breaker := NewConsecutiveBreaker("test",
+			WithConsecutiveThreshold(1),
+			WithConsecutiveBreakerSleepWindow(time.Second),
+			WithConsecutiveBreakerClock(clock))
+		breaker.Failure() // 下一次判断就会开启。
+
+		var wg sync.WaitGroup
+		var admitted int64
+		start := make(chan struct{})
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				for j := 0; j < 20; j++ {
+					if pass, _ := breaker.Allow(); pass {
+						atomic.AddInt64(&admitted, 1)
+					}
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+		if admitted != 0 {
+			t.Fatalf("round %d: ConsecutiveBreaker.Allow() admitted %d requests before sleep window, want 0", round, admitted)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package breaker
+
+import "sync"
+
+// CooldownGroup 用于协调共享同一依赖的多个 CutBreaker 的半开探测。
+// 同一组内同一时间只允许一个熔断器进入半开状态放请求探测，以免依赖恢复时被组内所有熔断器在相近的时间同时探测。
+// 探测结束（熔断器关闭或重新开启）后，组内其它熔断器才能进入半开状态。
+type CooldownGroup struct {
+	lock   sync.Mutex
+	prober *cutBreaker // 当前正在半开探测的熔断器，nil表示没有。
+}
+
+// NewCooldownGroup 用于新建一个 CooldownGroup。
+func NewCooldownGroup() *CooldownGroup {
+	return &CooldownGroup{}
+}
+
+// acquire 用于申请半开探测的资格，组内已有其它熔断器正在探测时返回false。
+func (g *CooldownGroup) acquire(b *cutBreaker) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.prober != nil && g.prober != b {
+		return false
+	}
+	g.prober = b
+	return true
+}
+
+// release 用于释放半开探测的资格，只有持有资格的熔断器才能释放。
+func (g *CooldownGroup) release(b *cutBreaker) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.prober == b {
+		g.prober = nil
+	}
+}
@@ -0,0 +1,66 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bunnier/circuit/breaker/internal"
+)
+
+// TestCooldownGroup 测试组内同一时间只有一个熔断器能进入半开状态。
+func TestCooldownGroup(t *testing.T) {
+	t.Parallel()
+	group := NewCooldownGroup()
+	breakers := make([]*cutBreaker, 3)
+	for i := range breakers {
+		breakers[i] = NewCutBreaker("test",
+			WithCutBreakerTimeWindow(5*time.Second),
+			WithCutBreakerSleepWindow(5*time.Second),
+			WithCutBreakerCooldownGroup(group))
+		breakers[i].internalStatus = Openning
+	}
+
+	// 休眠期已过。
+	summary := &internal.MetricSummary{LastExecuteTime: time.Now().Add(-10 * time.Second)}
+
+	// probe 让所有还处于开启状态的熔断器尝试进入半开状态，返回成功进入的熔断器。
+	probe := func() []*cutBreaker {
+		var probers []*cutBreaker
+		for _, b := range breakers {
+			if b.internalStatus != Openning {
+				continue
+			}
+			if pass, _ := b.allow(summary); pass {
+				probers = append(probers, b)
+			}
+		}
+		return probers
+	}
+
+	probers := probe()
+	if len(probers) != 1 {
+		t.Fatalf("half-open breakers got = %d, want 1", len(probers))
+	}
+	if others := probe(); len(others) != 0 { // 探测还没结束，其它熔断器依然开启。
+		t.Fatalf("half-open breakers got = %d, want 0", len(others))
+	}
+
+	probers[0].Success() // 探测成功，释放资格。
+	if probers[0].internalStatus != Closed {
+		t.Errorf("CutBreaker.internalStatus got = %v, want %v", probers[0].internalStatus, Closed)
+	}
+
+	probers = probe()
+	if len(probers) != 1 {
+		t.Fatalf("half-open breakers got = %d, want 1", len(probers))
+	}
+
+	probers[0].Failure() // 探测失败，重新开启并释放资格。
+	if probers[0].internalStatus != Openning {
+		t.Errorf("CutBreaker.internalStatus got = %v, want %v", probers[0].internalStatus, Openning)
+	}
+
+	if probers = probe(); len(probers) != 1 {
+		t.Fatalf("half-open breakers got = %d, want 1", len(probers))
+	}
+}
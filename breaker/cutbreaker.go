@@ -2,6 +2,8 @@ package breaker
 
 import (
 	"context"
+	"math/rand"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -9,6 +11,9 @@ import (
 )
 
 var _ Breaker = (*cutBreaker)(nil)
+var _ Configurable = (*cutBreaker)(nil)
+var _ StateNotifier = (*cutBreaker)(nil)
+var _ SuccessLatencyRecorder = (*cutBreaker)(nil)
 
 // cutBreaker 是 Breaker 的一种实现。
 type cutBreaker struct {
@@ -18,16 +23,54 @@ type cutBreaker struct {
 	metric *internal.Metric // 执行情况统计数据。
 
 	internalStatus int32 // 熔断器的内部状态，内部维护3个状态。
+	forcedStatus   int32 // 手动强制状态，优先于内部状态。
+	openedAt       int64 // 最后一次开启的时间（UnixNano），休眠时间从此刻起算。
+	openSleep      int64 // 最后一次开启时随机得到的休眠时间（纳秒），只在设置了sleepWindowJitter时使用。
+	halfOpenedAt   int64 // 最后一次进入半开状态的时间（UnixNano），探测超时从此刻起算。
+	firstEventTime int64 // 第一次记录事件的时间（UnixNano），预热时间从此刻起算，0表示还没有事件。
 
 	minRequestThreshold      int64         // 熔断器生效必须满足的最小流量。
 	errorThresholdPercentage float64       // 开启熔断的错误百分比阈值。
+	recencyWeighting         bool          // 判断是否开启熔断时，是否使用按时间线性加权的错误百分比。
+	slowCallDuration         time.Duration // 耗时超过该值的调用记为慢调用，为0时不统计慢调用。
+	slowCallRateThreshold    float64       // 开启熔断的慢调用百分比阈值。
 	sleepWindow              time.Duration // 熔断后重置熔断器的时间窗口。
+	sleepWindowJitter        float64       // 休眠时间的随机浮动比例（0-1），每次开启时在sleepWindow的±该比例内随机。
 	timeWindow               time.Duration // 滑动窗口的大小（单位秒1-60）。
+	bucketDuration           time.Duration // 滑动窗口中每个统计块的间隔（可选，默认1秒）。
+	clock                    Clock         // 休眠、预热与统计数据使用的时间源（可选）。
+	warmup                   time.Duration // 第一次记录事件后的预热时间，预热期间不会开启熔断器。
+
+	halfOpenMaxRequests int64         // 半开状态允许进入尝试的请求数量，全部成功才关闭。
+	halfOpenTimeout     time.Duration // 半开状态等待尝试请求结果的最长时间，为0时使用sleepWindow。
+	halfOpenSlots       int64         // 半开状态剩余可进入尝试的请求数量，小于0时拒绝。
+	halfOpenSuccesses   int64         // 半开状态已经成功的尝试请求数量。
+	halfOpenLock        sync.Mutex    // 用于保证同一时间只有一个请求从开启切换为半开状态。
+
+	cooldownGroup *CooldownGroup // 用于与其它熔断器协调半开探测（可选）。
+
+	onStateChange func(name string, from, to State)          // 状态变化时的回调函数。
+	onOpen        func(name string, summary *BreakerSummary) // 熔断器开启时的回调函数。
+	onClose       func(name string)                          // 熔断器关闭时的回调函数。
+	listeners     []func(name string, from, to State)        // 通过 NotifyStateChange 注册的状态变化监听函数。
+	stateChanges  []stateChange                              // 等待投递的状态变化事件，不限制长度，保证投递不会阻塞调用方。
+	stateLock     sync.Mutex                                 // 用于保护listeners与stateChanges。
+	stateChangeCh chan struct{}                              // 用于通知投递goroutine有新的状态变化事件。
+	notifying     int32                                      // 投递goroutine是否已经启动，启动后才记录状态变化事件。
+	notifyOnce    sync.Once                                  // 保证投递goroutine只启动一次。
+}
+
+// stateChange 记录一次熔断器状态变化。
+type stateChange struct {
+	from    State
+	to      State
+	summary *BreakerSummary // 切换到开启状态时的统计数据，其它切换为nil。
 }
 
 // NewCutBreaker 用于新建一个 CutBreaker 熔断器。
 // CutBreaker 提供一个“一刀切”的恢复算法。
 // 算法特点：内部维护开启、关闭、半开 三个状态，半开状态时只能有一个请求进入尝试，通过就重置统计，不通过重新完全开启熔断器。
+// 选项的取值超出范围时（如错误百分比阈值不在0-100之间、休眠时间不大于0）将panic，与滑动窗口大小错误时一致。
 func NewCutBreaker(name string, options ...CutBreakerOption) *cutBreaker {
 	b := &cutBreaker{
 		ctx:                      context.Background(),
@@ -36,22 +79,198 @@ func NewCutBreaker(name string, options ...CutBreakerOption) *cutBreaker {
 		minRequestThreshold:      20,     // 默认20个请求起算。
 		errorThresholdPercentage: 50,     // 默认50%。
 		sleepWindow:              time.Second * 5,
-		timeWindow:               5,
+		timeWindow:               time.Second * 5,
+		halfOpenMaxRequests:      1, // 默认半开状态只允许一个请求尝试。
+		slowCallRateThreshold:    100,
 	}
 
 	for _, option := range options {
 		option(b)
 	}
+	b.validate()
 
 	// 初始化选项后，根据选项初始化Metric。
-	b.metric = internal.NewMetric(
+	metricOptions := []internal.MerticOption{
 		internal.WithMetricTimeWindow(b.timeWindow),
-		internal.WithMetricContext(b.ctx),
-	)
+	}
+	if b.bucketDuration > 0 {
+		metricOptions = append(metricOptions, internal.WithMetricBucketDuration(b.bucketDuration))
+	}
+	if b.clock != nil {
+		metricOptions = append(metricOptions, internal.WithMetricClock(b.clock))
+	}
+	b.metric = internal.NewMetric(metricOptions...)
+
+	// 设置了回调函数才开启投递状态变化事件的goroutine，否则等到注册了监听函数时再开启。
+	b.stateChangeCh = make(chan struct{}, 1)
+	if b.onStateChange != nil || b.onOpen != nil || b.onClose != nil {
+		b.startStateChange()
+	}
 
 	return b
 }
 
+// validate 用于检查选项的取值范围，配置错误属于无法恢复的错误，直接panic。
+func (b *cutBreaker) validate() {
+	switch {
+	case b.timeWindow < time.Second || b.timeWindow > time.Minute:
+		panic("breaker: timeWindow invalid")
+	case b.errorThresholdPercentage <= 0 || b.errorThresholdPercentage > 100:
+		panic("breaker: errorThresholdPercentage invalid") // 为0时没有错误也会开启。
+	case b.minRequestThreshold < 0:
+		panic("breaker: minRequestThreshold invalid")
+	case b.sleepWindow <= 0:
+		panic("breaker: sleepWindow invalid")
+	case b.sleepWindowJitter < 0 || b.sleepWindowJitter > 1:
+		panic("breaker: sleepWindowJitter invalid")
+	case b.slowCallRateThreshold <= 0 || b.slowCallRateThreshold > 100:
+		panic("breaker: slowCallRateThreshold invalid")
+	case b.halfOpenMaxRequests < 1:
+		panic("breaker: halfOpenMaxRequests invalid")
+	}
+}
+
+// startStateChange 用于开启投递状态变化事件的goroutine，只有第一次调用生效。
+func (b *cutBreaker) startStateChange() {
+	b.notifyOnce.Do(func() {
+		b.runStateChange()
+		atomic.StoreInt32(&b.notifying, 1)
+	})
+}
+
+// runStateChange 用于在独立的goroutine中按顺序执行状态变化回调，以免阻塞调用方。
+func (b *cutBreaker) runStateChange() {
+	go func() {
+		for {
+			select {
+			case <-b.ctx.Done():
+				return // 结束。
+			case <-b.stateChangeCh:
+				b.stateLock.Lock()
+				changes, listeners := b.stateChanges, b.listeners
+				b.stateChanges = nil
+				b.stateLock.Unlock()
+
+				for _, change := range changes {
+					if b.onStateChange != nil {
+						b.onStateChange(b.name, change.from, change.to)
+					}
+					for _, listener := range listeners {
+						listener(b.name, change.from, change.to)
+					}
+					if change.to == StateOpen && b.onOpen != nil {
+						b.onOpen(b.name, change.summary)
+					}
+					if change.to == StateClosed && b.onClose != nil {
+						b.onClose(b.name)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// pushStateChange 用于投递一次状态变化事件。
+// 事件先放入不限长度的队列再通知投递goroutine，回调执行得再慢也不会阻塞调用方，也不会丢弃事件（如开启告警）。
+func (b *cutBreaker) pushStateChange(change stateChange) {
+	if b.ctx.Err() != nil {
+		return // 已释放资源，不再投递。
+	}
+	b.stateLock.Lock()
+	b.stateChanges = append(b.stateChanges, change)
+	b.stateLock.Unlock()
+
+	select {
+	case b.stateChangeCh <- struct{}{}:
+	default: // 已经有未处理的通知，投递goroutine会一并取走队列中的事件。
+	}
+}
+
+// transit 用于通过CAS切换熔断器内部状态，只有切换成功时才触发状态变化回调。
+func (b *cutBreaker) transit(from, to int32) bool {
+	return b.transitWith(from, to, nil)
+}
+
+// transitWith 与 transit 相同，summary 为触发开启的统计数据，见 onTransit。
+// 切换到开启状态时，先记录开启时间与本次的休眠时间再切换，并发的 allow 看到开启状态时，开启时间一定属于本次开启。
+func (b *cutBreaker) transitWith(from, to int32, summary *internal.MetricSummary) bool {
+	if to == Openning {
+		if atomic.LoadInt32(&b.internalStatus) != from { // 不会切换成功，不能改写其它轮次的开启时间。
+			return false
+		}
+		b.markOpen()
+	}
+	if !atomic.CompareAndSwapInt32(&b.internalStatus, from, to) {
+		return false
+	}
+	b.onTransit(from, to, summary)
+	return true
+}
+
+// markOpen 用于记录开启时间，设置了随机浮动时同时确定本次的休眠时间。
+// 并发切换时落选的一方也会记录，只会让开启时间稍晚一些，休眠时间不会缩短。
+func (b *cutBreaker) markOpen() {
+	if b.sleepWindowJitter > 0 { // 先确定本次的休眠时间，再记录开启时间。
+		jitter := b.sleepWindowJitter * (2*rand.Float64() - 1)
+		atomic.StoreInt64(&b.openSleep, int64(float64(b.sleepWindow)*(1+jitter)))
+	}
+	atomic.StoreInt64(&b.openedAt, b.now().UnixNano())
+}
+
+// onTransit 用于处理状态切换成功后的后续工作。
+// summary 为触发开启的统计数据，为nil时切换到开启状态将使用当前的统计数据。
+func (b *cutBreaker) onTransit(from, to int32, summary *internal.MetricSummary) {
+	if from == HalfOpening && b.cooldownGroup != nil { // 半开探测结束，释放组内的探测资格。
+		b.cooldownGroup.release(b)
+	}
+	if to != HalfOpening { // 不在半开状态时不允许任何尝试请求。
+		atomic.StoreInt64(&b.halfOpenSlots, 0)
+	}
+	if atomic.LoadInt32(&b.notifying) == 1 {
+		change := stateChange{State(from), State(to), nil}
+		if to == Openning && b.onOpen != nil {
+			if summary == nil {
+				summary = b.metric.Summary()
+			}
+			change.summary = newBreakerSummary(b.name, "open", summary, b.minRequestThreshold)
+		}
+		b.pushStateChange(change)
+	}
+}
+
+// NotifyStateChange 注册一个状态变化的监听函数，与 WithCutBreakerOnStateChange 在同一个goroutine中按状态变化的先后顺序执行。
+// 只通知注册之后的状态变化。
+func (b *cutBreaker) NotifyStateChange(listener func(name string, from, to State)) {
+	b.stateLock.Lock()
+	b.listeners = append(b.listeners, listener)
+	b.stateLock.Unlock()
+	b.startStateChange()
+}
+
+// getSleepWindow 返回本次开启的休眠时间，设置了随机浮动时为开启时随机得到的值。
+func (b *cutBreaker) getSleepWindow() time.Duration {
+	if b.sleepWindowJitter > 0 {
+		return time.Duration(atomic.LoadInt64(&b.openSleep))
+	}
+	return b.sleepWindow
+}
+
+// getHalfOpenTimeout 返回半开状态等待尝试请求结果的最长时间。
+func (b *cutBreaker) getHalfOpenTimeout() time.Duration {
+	if b.halfOpenTimeout > 0 {
+		return b.halfOpenTimeout
+	}
+	return b.sleepWindow
+}
+
+// now 返回当前时间，没有设置时间源时使用系统时间。
+func (b *cutBreaker) now() time.Time {
+	if b.clock != nil {
+		return b.clock.Now()
+	}
+	return time.Now()
+}
+
 // Allow 用于判断断路器是否允许通过请求。
 // 第一返回值：true能通过/false不能；第二返回值：当前Breaker状态的文字描述。
 func (b *cutBreaker) Allow() (bool, string) {
@@ -62,58 +281,144 @@ func (b *cutBreaker) Allow() (bool, string) {
 // allow 用于判断断路器是否允许通过请求。
 // 第一返回值：true能通过/false不能；第二返回值：当前Breaker状态的文字描述。
 func (b *cutBreaker) allow(summary *internal.MetricSummary) (bool, string) {
-	switch b.internalStatus {
+	if pass, statusMsg, forced := forcedAllow(&b.forcedStatus); forced {
+		return pass, statusMsg // 手动强制状态优先于自动判断。
+	}
+
+	switch atomic.LoadInt32(&b.internalStatus) {
 	case Closed:
-		// 没有满足最小流量要求 或 没有到达错误百分比阈值。
+		if b.warmingUp() { // 预热期间样本太少，不做判断。
+			return true, "closed"
+		}
+		errorPercentage := summary.ErrorPercentage
+		if b.recencyWeighting { // 按时间加权，最近的错误影响更大。
+			errorPercentage = summary.WeightedErrorPercentage
+		}
+		// 没有满足最小流量要求 或 错误百分比与慢调用百分比都没有到达阈值。
 		if summary.Total < b.minRequestThreshold ||
-			summary.ErrorPercentage < b.errorThresholdPercentage {
+			(errorPercentage < b.errorThresholdPercentage && !b.tooManySlowCalls(summary)) {
 			return true, "closed"
 		}
 		// 开启熔断器，Closed应该不会马上变化为除Open外的其它状态，不过安全起见，还是通过CAS赋值把。
-		atomic.CompareAndSwapInt32(&b.internalStatus, Closed, Openning)
+		// 开启回调拿到的是触发开启的统计数据。
+		b.transitWith(Closed, Openning, summary)
 		return false, "open" // 无论上面结果如何，都开启。
 
 	case HalfOpening:
-		return false, "half-open" // 半开状态，说明已经有一个请求正在尝试，拒绝所有其它请求。
+		// 尝试请求迟迟没有结果（如功能函数挂起），重新开启，等下一个休眠周期再尝试，以免一直停留在半开状态。
+		if b.now().Sub(time.Unix(0, atomic.LoadInt64(&b.halfOpenedAt))) >= b.getHalfOpenTimeout() {
+			b.transit(HalfOpening, Openning)
+			return false, "open"
+		}
+		// 半开状态，说明已经有请求正在尝试，还有剩余尝试数量的才放行，拒绝所有其它请求。
+		return atomic.AddInt64(&b.halfOpenSlots, -1) >= 0, "half-open"
 
 	case Openning:
-		// 判断是否已过休眠时间。
-		if time.Since(summary.LastExecuteTime) < b.sleepWindow {
+		// 判断是否已过休眠时间，从开启时刻起算，不受开启期间其它统计事件的影响。
+		if b.now().Sub(time.Unix(0, atomic.LoadInt64(&b.openedAt))) < b.getSleepWindow() {
 			return false, "open"
 		}
 		// 过了休眠时间，设置为半开状态，并放一个请求试试。
-		// 这里可能并发，用个CAS控制，换不到的还是开启，换到的就关闭一次。
-		return atomic.CompareAndSwapInt32(&b.internalStatus, Openning, HalfOpening), "half-open"
+		return b.tryHalfOpen()
 
 	default:
 		panic("breaker: impossible status")
 	}
 }
 
+// warmingUp 用于判断熔断器是否处于预热期间，没有设置预热时间时总是返回false。
+func (b *cutBreaker) warmingUp() bool {
+	if b.warmup <= 0 {
+		return false
+	}
+	first := atomic.LoadInt64(&b.firstEventTime)
+	return first == 0 || b.now().Sub(time.Unix(0, first)) < b.warmup
+}
+
+// markEvent 用于记录第一次事件的时间，只有第一次调用生效。
+func (b *cutBreaker) markEvent() {
+	if b.warmup > 0 && atomic.LoadInt64(&b.firstEventTime) == 0 {
+		atomic.CompareAndSwapInt64(&b.firstEventTime, 0, b.now().UnixNano())
+	}
+}
+
+// tooManySlowCalls 用于判断慢调用百分比是否到达阈值，没有设置慢调用标准时总是返回false。
+func (b *cutBreaker) tooManySlowCalls(summary *internal.MetricSummary) bool {
+	return b.slowCallDuration > 0 && summary.SlowCallPercentage >= b.slowCallRateThreshold
+}
+
+// tryHalfOpen 用于从开启状态切换为半开状态，切换成功的请求本身就是第一个尝试请求。
+// 半开状态的并发约束：
+//  1. 同一时间只有一个请求能执行切换，其它并发请求返回false；
+//  2. 本轮的计数（尝试数量、成功数量、开始时间）在状态对外生效前初始化，Success/Failure 看到半开状态时，计数一定属于本轮；
+//  3. 每轮最多放行halfOpenMaxRequests个尝试请求，全部成功才关闭，任意一个失败或超时都重新开启；
+//  4. Breaker接口不区分请求，半开期间记录的所有结果都视为尝试请求的结果，包括开启前放行、此时才返回的请求。
+func (b *cutBreaker) tryHalfOpen() (bool, string) {
+	b.halfOpenLock.Lock()
+	defer b.halfOpenLock.Unlock()
+	if atomic.LoadInt32(&b.internalStatus) != Openning { // 已经被其它请求切换了。
+		return false, "half-open"
+	}
+
+	// 如果设置了CooldownGroup，还需要拿到组内的探测资格，拿不到的依然开启。
+	if b.cooldownGroup != nil && !b.cooldownGroup.acquire(b) {
+		return false, "open"
+	}
+
+	// 先初始化计数再对外生效。
+	atomic.StoreInt64(&b.halfOpenedAt, b.now().UnixNano())
+	atomic.StoreInt64(&b.halfOpenSuccesses, 0)
+	atomic.StoreInt64(&b.halfOpenSlots, b.halfOpenMaxRequests-1)
+	if !atomic.CompareAndSwapInt32(&b.internalStatus, Openning, HalfOpening) { // 期间被 ResetState 关闭了。
+		if b.cooldownGroup != nil {
+			b.cooldownGroup.release(b)
+		}
+		return false, "closed"
+	}
+	b.onTransit(Openning, HalfOpening, nil)
+	return true, "half-open"
+}
+
 // Success 用于记录成功事件。
 func (b *cutBreaker) Success() {
-	if b.internalStatus == HalfOpening {
+	b.markEvent()
+	// 半开状态下，尝试请求全部成功才关闭。
+	if atomic.LoadInt32(&b.internalStatus) == HalfOpening &&
+		atomic.AddInt64(&b.halfOpenSuccesses, 1) >= b.halfOpenMaxRequests {
 		b.metric.Reset() // 注意：这里需要先Reset metric再改状态，否则会有并发问题。
-		// HalfOpening状态目前的实现不会有并发，但还是顺手用CAS吧。
-		atomic.CompareAndSwapInt32(&b.internalStatus, HalfOpening, Closed)
+		b.transit(HalfOpening, Closed)
 	}
 	b.metric.Success()
 }
 
 // Failure 用于记录失败事件。
 func (b *cutBreaker) Failure() {
-	// HalfOpening状态目前的实现不会有并发，但还是顺手用CAS吧。
-	atomic.CompareAndSwapInt32(&b.internalStatus, HalfOpening, Openning)
+	b.markEvent()
+	// 半开状态下，任意一个尝试请求失败都重新开启。
+	b.transit(HalfOpening, Openning)
 	b.metric.Failure()
 }
 
 // Timeout 用于记录失败事件。
 func (b *cutBreaker) Timeout() {
-	// HalfOpening状态目前的实现不会有并发，但还是顺手用CAS吧。
-	atomic.CompareAndSwapInt32(&b.internalStatus, HalfOpening, Openning)
+	b.markEvent()
+	// 半开状态下，任意一个尝试请求失败都重新开启。
+	b.transit(HalfOpening, Openning)
 	b.metric.Timeout()
 }
 
+// Latency 记录一次功能函数执行耗时。
+func (b *cutBreaker) Latency(d time.Duration) {
+	b.metric.Latency(d)
+}
+
+// SuccessLatency 记录一次成功执行的耗时，设置了慢调用标准且耗时超过标准时记为慢调用，失败与超时的执行不计入慢调用。
+func (b *cutBreaker) SuccessLatency(d time.Duration) {
+	if b.slowCallDuration > 0 && d > b.slowCallDuration {
+		b.metric.SlowCall()
+	}
+}
+
 // FallbackSuccess 记录一次降级函数执行成功事件。
 func (b *cutBreaker) FallbackSuccess() {
 	b.metric.FallbackSuccess()
@@ -121,55 +426,139 @@ func (b *cutBreaker) FallbackSuccess() {
 
 // FallbackFailure 记录一次降级函数执行失败事件。
 func (b *cutBreaker) FallbackFailure() {
-	b.metric.FallbackSuccess()
+	b.metric.FallbackFailure()
 }
 
 // Summary 返回当前健康状态。
 func (b *cutBreaker) Summary() *BreakerSummary {
-	summary := b.metric.Summary() // 当前健康统计。
-	_, statusStr := b.allow(summary)
-	return &BreakerSummary{
-		Status:               statusStr,
-		TimeWindowSecond:     summary.TimeWindowSecond,
-		MetricIntervalSecond: summary.MetricIntervalSecond,
-		Success:              summary.Success,
-		Timeout:              summary.Timeout,
-		Failure:              summary.Failure,
-		FallbackSuccess:      summary.FallbackSuccess,
-		FallbackFailure:      summary.FallbackFailure,
-		Total:                summary.Total,
-		ErrorPercentage:      summary.ErrorPercentage,
-		LastExecuteTime:      summary.LastExecuteTime,
-		LastSuccessTime:      summary.LastSuccessTime,
-		LastTimeoutTime:      summary.LastTimeoutTime,
-		LastFailureTime:      summary.LastFailureTime,
+	return newBreakerSummary(b.name, b.status(), b.metric.Summary(), b.minRequestThreshold)
+}
+
+// Drain 返回当前健康状态，并同时重置上报用的统计数据。
+func (b *cutBreaker) Drain() *BreakerSummary {
+	return newBreakerSummary(b.name, b.status(), b.metric.Drain(), b.minRequestThreshold)
+}
+
+// status 返回当前状态的文字描述，只读取状态，不会像 allow 一样切换状态或占用半开状态的尝试数量。
+func (b *cutBreaker) status() string {
+	if _, statusMsg, forced := forcedAllow(&b.forcedStatus); forced {
+		return statusMsg
 	}
+	return State(atomic.LoadInt32(&b.internalStatus)).String()
+}
+
+// LatencyBuckets 返回滑动窗口中按时间分布的耗时直方图。
+func (b *cutBreaker) LatencyBuckets() [][]int64 {
+	return b.metric.LatencyBuckets()
+}
+
+// State 返回熔断器当前状态。
+func (b *cutBreaker) State() State {
+	if isForced(&b.forcedStatus) {
+		return StateForced
+	}
+	return State(atomic.LoadInt32(&b.internalStatus))
+}
+
+// Config 返回熔断器的有效配置。
+func (b *cutBreaker) Config() BreakerConfig {
+	return BreakerConfig{
+		Type: "cut",
+		Options: map[string]interface{}{
+			"timeWindow":               b.timeWindow,
+			"errorThresholdPercentage": b.errorThresholdPercentage,
+			"minRequestThreshold":      b.minRequestThreshold,
+			"sleepWindow":              b.sleepWindow,
+			"sleepWindowJitter":        b.sleepWindowJitter,
+			"halfOpenMaxRequests":      b.halfOpenMaxRequests,
+			"halfOpenTimeout":          b.getHalfOpenTimeout(),
+			"cooldownGroup":            b.cooldownGroup != nil,
+			"recencyWeighting":         b.recencyWeighting,
+			"warmup":                   b.warmup,
+			"bucketDuration":           b.bucketDuration,
+			"slowCallDuration":         b.slowCallDuration,
+			"slowCallRateThreshold":    b.slowCallRateThreshold,
+		},
+	}
+}
+
+// ForceOpen 用于手动强制开启熔断器，强制期间拒绝所有请求。
+func (b *cutBreaker) ForceOpen() {
+	atomic.StoreInt32(&b.forcedStatus, forcedOpen)
+}
+
+// ForceClose 用于手动强制关闭熔断器，强制期间放行所有请求。
+func (b *cutBreaker) ForceClose() {
+	atomic.StoreInt32(&b.forcedStatus, forcedClosed)
+}
+
+// ClearForced 用于清除手动强制状态，恢复熔断器的自动判断。
+func (b *cutBreaker) ClearForced() {
+	atomic.StoreInt32(&b.forcedStatus, forcedNone)
+}
+
+// ResetMetrics 用于清空统计数据，不改变熔断器状态。
+func (b *cutBreaker) ResetMetrics() {
+	b.resetMetric()
+}
+
+// resetMetric 用于清空统计数据，并重新开始预热。
+func (b *cutBreaker) resetMetric() {
+	b.metric.Reset()
+	atomic.StoreInt64(&b.firstEventTime, 0)
+}
+
+// ResetState 用于将熔断器重置为关闭状态，clearMetrics 为true时同时清空统计数据。
+// 保留统计数据时，如果错误率依然超过阈值，下一次判断时会重新开启。
+func (b *cutBreaker) ResetState(clearMetrics bool) {
+	if clearMetrics {
+		b.resetMetric() // 先Reset metric再改状态，与半开状态恢复时的顺序一致。
+	}
+	for {
+		status := atomic.LoadInt32(&b.internalStatus)
+		if status == Closed || b.transit(status, Closed) {
+			return
+		}
+	}
+}
+
+// Reset 用于立即关闭熔断器，并清空统计数据。
+func (b *cutBreaker) Reset() {
+	b.ResetState(true)
 }
 
 // CutBreakerOption 是 CutBreaker 的可选项。
 type CutBreakerOption func(b *cutBreaker)
 
-// WithCutBreakerMinRequestThreshold 设置熔断器生效必须满足的最小流量。
+// WithCutBreakerMinRequestThreshold 设置熔断器生效必须满足的最小流量（不能小于0）。
 func WithCutBreakerMinRequestThreshold(minRequestThreshold int64) CutBreakerOption {
 	return func(b *cutBreaker) {
 		b.minRequestThreshold = minRequestThreshold
 	}
 }
 
-// WithCutBreakerErrorThresholdPercentage 设置熔断器生效必须满足的错误百分比。
+// WithCutBreakerErrorThresholdPercentage 设置熔断器生效必须满足的错误百分比（要求大于0且不超过100）。
 func WithCutBreakerErrorThresholdPercentage(errorThresholdPercentage float64) CutBreakerOption {
 	return func(b *cutBreaker) {
 		b.errorThresholdPercentage = errorThresholdPercentage
 	}
 }
 
-// WithCutBreakerSleepWindow 设置熔断后重置熔断器的时间窗口。
+// WithCutBreakerSleepWindow 设置熔断后重置熔断器的时间窗口（要求大于0）。
 func WithCutBreakerSleepWindow(sleepWindow time.Duration) CutBreakerOption {
 	return func(b *cutBreaker) {
 		b.sleepWindow = sleepWindow
 	}
 }
 
+// WithCutBreakerSleepWindowJitter 设置休眠时间的随机浮动比例（0-1，默认0不浮动），每次开启时在休眠时间的±fraction内随机。
+// 大量实例或Command因同一个故障同时开启时，随机的休眠时间可以错开半开探测，避免同时向下游发起探测。
+func WithCutBreakerSleepWindowJitter(fraction float64) CutBreakerOption {
+	return func(b *cutBreaker) {
+		b.sleepWindowJitter = fraction
+	}
+}
+
 // WithCutBreakerTimeWindow 设置滑动窗口的大小（要求1-60s）。
 func WithCutBreakerTimeWindow(timeWindow time.Duration) CutBreakerOption {
 	return func(b *cutBreaker) {
@@ -177,9 +566,105 @@ func WithCutBreakerTimeWindow(timeWindow time.Duration) CutBreakerOption {
 	}
 }
 
+// WithCutBreakerBucketDuration 设置滑动窗口中每个统计块的间隔（默认1秒），可以小于1秒，窗口大小必须是它的整数倍。
+// 间隔越小，过期数据滑出窗口越平滑，如1s窗口设置100ms的统计块，错误高峰过去后最快100ms就能开始恢复。
+func WithCutBreakerBucketDuration(d time.Duration) CutBreakerOption {
+	return func(b *cutBreaker) {
+		b.bucketDuration = d
+	}
+}
+
+// WithCutBreakerHalfOpenMaxRequests 设置半开状态允许进入尝试的请求数量（默认1）。
+// 尝试请求连续成功n次后关闭熔断器，任意一次失败则重新开启。
+func WithCutBreakerHalfOpenMaxRequests(n int64) CutBreakerOption {
+	return func(b *cutBreaker) {
+		b.halfOpenMaxRequests = n
+	}
+}
+
+// WithCutBreakerHalfOpenTimeout 设置半开状态等待尝试请求结果的最长时间（默认与休眠时间相同）。
+// 超过该时间尝试请求仍没有结果（如功能函数挂起且超时时间很长）时，重新开启熔断器，等下一个休眠周期再尝试。
+func WithCutBreakerHalfOpenTimeout(d time.Duration) CutBreakerOption {
+	return func(b *cutBreaker) {
+		b.halfOpenTimeout = d
+	}
+}
+
+// WithCutBreakerOnStateChange 设置熔断器状态变化时的回调函数。
+// 回调在独立的goroutine中按状态变化的先后顺序执行，不会阻塞调用方：
+// 事件放入不限长度的队列中，回调执行较慢时事件会在队列中累积，而不是丢弃，回调应尽快返回。
+func WithCutBreakerOnStateChange(onStateChange func(name string, from, to State)) CutBreakerOption {
+	return func(b *cutBreaker) {
+		b.onStateChange = onStateChange
+	}
+}
+
+// WithCutBreakerOnOpen 设置熔断器开启时的回调函数（如发送告警），summary 为触发开启时的统计数据，包含错误百分比。
+// 从关闭或半开状态开启时都会触发，与 WithCutBreakerOnStateChange 在同一个goroutine中按状态变化的先后顺序执行。
+// 回调执行较慢时事件在队列中累积，不会阻塞调用方，也不会丢失告警。
+func WithCutBreakerOnOpen(onOpen func(name string, summary *BreakerSummary)) CutBreakerOption {
+	return func(b *cutBreaker) {
+		b.onOpen = onOpen
+	}
+}
+
+// WithCutBreakerOnClose 设置熔断器关闭（恢复）时的回调函数，半开探测成功与手动重置时都会触发。
+// 与 WithCutBreakerOnStateChange 在同一个goroutine中按状态变化的先后顺序执行，同样不会阻塞调用方。
+func WithCutBreakerOnClose(onClose func(name string)) CutBreakerOption {
+	return func(b *cutBreaker) {
+		b.onClose = onClose
+	}
+}
+
+// WithCutBreakerCooldownGroup 设置熔断器所属的 CooldownGroup，组内同一时间只允许一个熔断器进入半开状态探测。
+func WithCutBreakerCooldownGroup(group *CooldownGroup) CutBreakerOption {
+	return func(b *cutBreaker) {
+		b.cooldownGroup = group
+	}
+}
+
+// WithCutBreakerClock 设置休眠时间、预热时间与统计数据使用的时间源（默认系统时间），用于在测试中驱动时间，不需要真实等待。
+func WithCutBreakerClock(clock Clock) CutBreakerOption {
+	return func(b *cutBreaker) {
+		b.clock = clock
+	}
+}
+
 // WithCutBreakerContext 设置用于释放资源的context。
 func WithCutBreakerContext(ctx context.Context) CutBreakerOption {
 	return func(b *cutBreaker) {
 		b.ctx = ctx
 	}
 }
+
+// WithCutBreakerRecencyWeighting 设置判断是否开启熔断时，是否按时间线性加权计算错误百分比（默认否）。
+// 加权后越新的统计块权重越高，能更快地对刚出现的错误高峰做出反应；Summary 中的错误百分比依然不加权。
+func WithCutBreakerRecencyWeighting(recencyWeighting bool) CutBreakerOption {
+	return func(b *cutBreaker) {
+		b.recencyWeighting = recencyWeighting
+	}
+}
+
+// WithCutBreakerWarmup 设置预热时间（默认0，不预热）。
+// 从第一次记录事件（或清空统计数据后第一次记录事件）起算，预热期间无论错误百分比多少都不会开启熔断器，以便滑动窗口积累足够的样本。
+func WithCutBreakerWarmup(d time.Duration) CutBreakerOption {
+	return func(b *cutBreaker) {
+		b.warmup = d
+	}
+}
+
+// WithCutBreakerSlowCallDurationThreshold 设置慢调用的标准（默认0，不统计慢调用），耗时超过该值且执行成功的调用记为慢调用。
+// 慢调用百分比为慢调用占全部请求的百分比，失败与超时只计入错误百分比；任意一个到达阈值都会开启熔断器，用于发现下游变慢但没有报错的情况。
+// 成功执行的耗时通过 SuccessLatency 记录，通过 Command 使用时会自动调用。
+func WithCutBreakerSlowCallDurationThreshold(d time.Duration) CutBreakerOption {
+	return func(b *cutBreaker) {
+		b.slowCallDuration = d
+	}
+}
+
+// WithCutBreakerSlowCallRateThreshold 设置开启熔断的慢调用百分比阈值（默认100%），需要同时设置 WithCutBreakerSlowCallDurationThreshold。
+func WithCutBreakerSlowCallRateThreshold(pct float64) CutBreakerOption {
+	return func(b *cutBreaker) {
+		b.slowCallRateThreshold = pct
+	}
+}
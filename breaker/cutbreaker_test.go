@@ -1,7 +1,9 @@
 package breaker
 
 import (
+	"math"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -15,6 +17,7 @@ func TestCutBreaker_allow(t *testing.T) {
 		name                  string
 		healthSummary         *internal.MetricSummary
 		breakerInternalStatus int32
+		openedAt              time.Time
 		allow                 bool
 		statusString          string
 	}{
@@ -30,7 +33,7 @@ func TestCutBreaker_allow(t *testing.T) {
 			LastSuccessTime: time.Now(),
 			LastTimeoutTime: time.Now(),
 			LastFailureTime: time.Now(),
-		}, Closed, false, "open"},
+		}, Closed, time.Time{}, false, "open"},
 		{"case2", &internal.MetricSummary{
 			Success:         0,
 			Timeout:         4,
@@ -43,7 +46,7 @@ func TestCutBreaker_allow(t *testing.T) {
 			LastSuccessTime: time.Now(),
 			LastTimeoutTime: time.Now(),
 			LastFailureTime: time.Now(),
-		}, Closed, true, "closed"},
+		}, Closed, time.Time{}, true, "closed"},
 		{"case3", &internal.MetricSummary{
 			Success:         0,
 			Timeout:         4,
@@ -56,7 +59,7 @@ func TestCutBreaker_allow(t *testing.T) {
 			LastSuccessTime: time.Now(),
 			LastTimeoutTime: time.Now(),
 			LastFailureTime: time.Now(),
-		}, HalfOpening, false, "half-open"},
+		}, HalfOpening, time.Time{}, false, "half-open"},
 		{"case4", &internal.MetricSummary{
 			Success:         0,
 			Timeout:         5,
@@ -65,11 +68,11 @@ func TestCutBreaker_allow(t *testing.T) {
 			FallbackFailure: 0,
 			Total:           20,
 			ErrorPercentage: 100,
-			LastExecuteTime: time.Now().Add(-time.Second * 10),
+			LastExecuteTime: time.Now(),
 			LastSuccessTime: time.Now(),
 			LastTimeoutTime: time.Now(),
 			LastFailureTime: time.Now(),
-		}, Openning, true, "half-open"},
+		}, Openning, time.Now().Add(-time.Second * 10), true, "half-open"},
 		{"case5", &internal.MetricSummary{
 			Success:         0,
 			Timeout:         5,
@@ -78,11 +81,11 @@ func TestCutBreaker_allow(t *testing.T) {
 			FallbackFailure: 0,
 			Total:           20,
 			ErrorPercentage: 100,
-			LastExecuteTime: time.Now().Add(-time.Second * 3),
+			LastExecuteTime: time.Now().Add(-time.Second * 10),
 			LastSuccessTime: time.Now(),
 			LastTimeoutTime: time.Now(),
 			LastFailureTime: time.Now(),
-		}, Openning, false, "open"},
+		}, Openning, time.Now().Add(-time.Second * 3), false, "open"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -92,6 +95,12 @@ func TestCutBreaker_allow(t *testing.T) {
 				WithCutBreakerMinRequestThreshold(20),
 				WithCutBreakerSleepWindow(5*time.Second))
 			breaker.internalStatus = tt.breakerInternalStatus
+			if !tt.openedAt.IsZero() {
+				breaker.openedAt = tt.openedAt.UnixNano()
+			}
+			if tt.breakerInternalStatus == HalfOpening { // 尝试请求刚刚进入，还没有超时。
+				breaker.halfOpenedAt = time.Now().UnixNano()
+			}
 
 			got, got1 := breaker.allow(tt.healthSummary)
 			if got != tt.allow {
@@ -104,14 +113,16 @@ func TestCutBreaker_allow(t *testing.T) {
 	}
 }
 
-// TestCutBreaker_workflow 测试熔断器的完整工作流程。
+// TestCutBreaker_workflow 测试熔断器的完整工作流程，通过可控的时间源推进休眠时间，不需要真实等待。
 func TestCutBreaker_workflow(t *testing.T) {
 	t.Parallel()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
 	breaker := NewCutBreaker("test",
 		WithCutBreakerTimeWindow(5*time.Second),
 		WithCutBreakerErrorThresholdPercentage(50),
 		WithCutBreakerMinRequestThreshold(20),
-		WithCutBreakerSleepWindow(2*time.Second))
+		WithCutBreakerSleepWindow(2*time.Second),
+		WithCutBreakerClock(clock))
 
 	var wg sync.WaitGroup
 	for i := 0; i < 1000; i++ {
@@ -141,7 +152,7 @@ func TestCutBreaker_workflow(t *testing.T) {
 		t.Errorf("CutBreaker.Allow() got = %v, want %v", pass, false)
 	}
 
-	time.Sleep(2 * time.Second)
+	clock.Advance(2 * time.Second)
 	// 睡眠期结束，应该可以进入半熔断了。
 	if pass, statusMsg := breaker.Allow(); !pass {
 		t.Errorf("CutBreaker.Allow() got = %v, want %v", pass, true)
@@ -154,7 +165,7 @@ func TestCutBreaker_workflow(t *testing.T) {
 		t.Errorf("CutBreaker.Allow() got = %v, want %v", pass, false)
 	}
 
-	time.Sleep(2 * time.Second)
+	clock.Advance(2 * time.Second)
 	// 睡眠期结束，应该可以进入半熔断了。
 	if pass, statusMsg := breaker.Allow(); !pass {
 		t.Errorf("CutBreaker.Allow() got = %v, want %v", pass, true)
@@ -167,3 +178,870 @@ func TestCutBreaker_workflow(t *testing.T) {
 		t.Errorf("CutBreaker.Allow() got = %v, want %v", pass, true)
 	}
 }
+
+// TestCutBreaker_forced 测试手动强制状态优先于统计数据的判断。
+func TestCutBreaker_forced(t *testing.T) {
+	t.Parallel()
+	breaker := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(5*time.Second),
+		WithCutBreakerErrorThresholdPercentage(50),
+		WithCutBreakerMinRequestThreshold(20),
+		WithCutBreakerSleepWindow(5*time.Second))
+
+	healthy := &internal.MetricSummary{Success: 100, Total: 100, LastExecuteTime: time.Now()}
+	unhealthy := &internal.MetricSummary{Failure: 100, Total: 100, ErrorPercentage: 100, LastExecuteTime: time.Now()}
+
+	// 强制关闭时，即使错误率超过阈值也放行，且不会改变内部状态。
+	breaker.ForceClose()
+	if pass, statusMsg := breaker.allow(unhealthy); !pass || statusMsg != "forced-closed" {
+		t.Errorf("CutBreaker.allow() got = %v, %v, want %v, %v", pass, statusMsg, true, "forced-closed")
+	}
+	if breaker.internalStatus != Closed {
+		t.Errorf("CutBreaker.internalStatus got = %v, want %v", breaker.internalStatus, Closed)
+	}
+
+	// 强制开启时，即使完全健康也拒绝。
+	breaker.ForceOpen()
+	if pass, statusMsg := breaker.allow(healthy); pass || statusMsg != "forced-open" {
+		t.Errorf("CutBreaker.allow() got = %v, %v, want %v, %v", pass, statusMsg, false, "forced-open")
+	}
+
+	// 清除强制状态后恢复自动判断。
+	breaker.ClearForced()
+	if pass, statusMsg := breaker.allow(healthy); !pass || statusMsg != "closed" {
+		t.Errorf("CutBreaker.allow() got = %v, %v, want %v, %v", pass, statusMsg, true, "closed")
+	}
+	if pass, statusMsg := breaker.allow(unhealthy); pass || statusMsg != "open" {
+		t.Errorf("CutBreaker.allow() got = %v, %v, want %v, %v", pass, statusMsg, false, "open")
+	}
+
+	// 统计数据驱动开启后，强制关闭依然优先。
+	breaker.ForceClose()
+	if pass, _ := breaker.Allow(); !pass {
+		t.Errorf("CutBreaker.Allow() got = %v, want %v", pass, true)
+	}
+	if summary := breaker.Summary(); summary.Status != "forced-closed" {
+		t.Errorf("CutBreaker.Summary() Status got = %v, want %v", summary.Status, "forced-closed")
+	}
+}
+
+// TestCutBreaker_State 测试熔断器状态的类型化表示。
+func TestCutBreaker_State(t *testing.T) {
+	t.Parallel()
+	breaker := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(5*time.Second),
+		WithCutBreakerErrorThresholdPercentage(50),
+		WithCutBreakerMinRequestThreshold(20),
+		WithCutBreakerSleepWindow(5*time.Second))
+
+	if state := breaker.State(); state != StateClosed {
+		t.Errorf("CutBreaker.State() got = %v, want %v", state, StateClosed)
+	}
+
+	unhealthy := &internal.MetricSummary{Failure: 100, Total: 100, ErrorPercentage: 100, LastExecuteTime: time.Now()}
+	breaker.allow(unhealthy)
+	if state := breaker.State(); state != StateOpen {
+		t.Errorf("CutBreaker.State() got = %v, want %v", state, StateOpen)
+	}
+
+	breaker.openedAt = time.Now().Add(-10 * time.Second).UnixNano() // 休眠期已过。
+	breaker.allow(unhealthy)
+	if state := breaker.State(); state != StateHalfOpen {
+		t.Errorf("CutBreaker.State() got = %v, want %v", state, StateHalfOpen)
+	}
+
+	breaker.ForceOpen()
+	if state := breaker.State(); state != StateForced {
+		t.Errorf("CutBreaker.State() got = %v, want %v", state, StateForced)
+	}
+}
+
+// TestCutBreaker_sleepWindowJitter 测试每次开启的休眠时间在±浮动比例内随机，且各次不同。
+func TestCutBreaker_sleepWindowJitter(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(5*time.Second),
+		WithCutBreakerSleepWindow(10*time.Second),
+		WithCutBreakerSleepWindowJitter(0.2),
+		WithCutBreakerClock(clock))
+
+	unhealthy := &internal.MetricSummary{Failure: 100, Total: 100, ErrorPercentage: 100}
+	breaker.allow(unhealthy) // Closed→Open。
+
+	const step = 100 * time.Millisecond
+	minSleep, maxSleep := time.Duration(math.MaxInt64), time.Duration(0)
+	for i := 0; i < 200; i++ {
+		// 逐步推进时间，直到进入半开状态。
+		var elapsed time.Duration
+		for {
+			if pass, _ := breaker.allow(unhealthy); pass {
+				break
+			}
+			if elapsed > 13*time.Second {
+				t.Fatalf("trial %d: CutBreaker not half-open after %v", i, elapsed)
+			}
+			clock.Advance(step)
+			elapsed += step
+		}
+		if elapsed < 8*time.Second || elapsed > 12*time.Second+step {
+			t.Errorf("trial %d: half-open after %v, want in [%v, %v]", i, elapsed, 8*time.Second, 12*time.Second)
+		}
+		if elapsed < minSleep {
+			minSleep = elapsed
+		}
+		if elapsed > maxSleep {
+			maxSleep = elapsed
+		}
+		breaker.Failure() // HalfOpen→Open，重新随机休眠时间。
+	}
+	if maxSleep-minSleep < time.Second {
+		t.Errorf("half-open time range got = [%v, %v], want spread across the jitter range", minSleep, maxSleep)
+	}
+}
+
+// TestNewCutBreaker_defaultOptions 测试不传入任何选项时使用默认值正常创建。
+func TestNewCutBreaker_defaultOptions(t *testing.T) {
+	t.Parallel()
+	defer func() {
+		if got := recover(); got != nil {
+			t.Errorf("NewCutBreaker() panic got = %v, want %v", got, nil)
+		}
+	}()
+	b := NewCutBreaker("x")
+	if got := b.Summary().TimeWindowSecond; got != 5 {
+		t.Errorf("CutBreaker.Summary() TimeWindowSecond got = %d, want %d", got, 5)
+	}
+	if pass, _ := b.Allow(); !pass {
+		t.Errorf("CutBreaker.Allow() got = %v, want %v", pass, true)
+	}
+}
+
+// TestNewCutBreaker_invalidOptions 测试选项的取值超出范围时panic，边界值可以正常创建。
+func TestNewCutBreaker_invalidOptions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		option CutBreakerOption
+		want   interface{} // 期望的panic值，nil表示不panic。
+	}{
+		{"errorThresholdPercentage negative", WithCutBreakerErrorThresholdPercentage(-1), "breaker: errorThresholdPercentage invalid"},
+		{"errorThresholdPercentage zero", WithCutBreakerErrorThresholdPercentage(0), "breaker: errorThresholdPercentage invalid"},
+		{"errorThresholdPercentage over 100", WithCutBreakerErrorThresholdPercentage(101), "breaker: errorThresholdPercentage invalid"},
+		{"errorThresholdPercentage 100", WithCutBreakerErrorThresholdPercentage(100), nil},
+		{"minRequestThreshold negative", WithCutBreakerMinRequestThreshold(-1), "breaker: minRequestThreshold invalid"},
+		{"minRequestThreshold zero", WithCutBreakerMinRequestThreshold(0), nil},
+		{"sleepWindow zero", WithCutBreakerSleepWindow(0), "breaker: sleepWindow invalid"},
+		{"sleepWindow negative", WithCutBreakerSleepWindow(-time.Second), "breaker: sleepWindow invalid"},
+		{"sleepWindowJitter negative", WithCutBreakerSleepWindowJitter(-0.1), "breaker: sleepWindowJitter invalid"},
+		{"sleepWindowJitter over 1", WithCutBreakerSleepWindowJitter(1.5), "breaker: sleepWindowJitter invalid"},
+		{"slowCallRateThreshold zero", WithCutBreakerSlowCallRateThreshold(0), "breaker: slowCallRateThreshold invalid"},
+		{"halfOpenMaxRequests zero", WithCutBreakerHalfOpenMaxRequests(0), "breaker: halfOpenMaxRequests invalid"},
+		{"timeWindow under 1s", WithCutBreakerTimeWindow(5), "breaker: timeWindow invalid"},
+		{"timeWindow over 60s", WithCutBreakerTimeWindow(61 * time.Second), "breaker: timeWindow invalid"},
+		{"timeWindow 60s", WithCutBreakerTimeWindow(time.Minute), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if got := recover(); got != tt.want {
+					t.Errorf("NewCutBreaker() panic got = %v, want %v", got, tt.want)
+				}
+			}()
+			NewCutBreaker("test", WithCutBreakerTimeWindow(5*time.Second), tt.option)
+		})
+	}
+}
+
+// TestCutBreaker_onStateChange 测试状态变化回调按顺序触发，且CAS失败时不触发。
+func TestCutBreaker_onStateChange(t *testing.T) {
+	t.Parallel()
+	changeCh := make(chan [2]State, 10)
+	breaker := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(5*time.Second),
+		WithCutBreakerErrorThresholdPercentage(50),
+		WithCutBreakerMinRequestThreshold(20),
+		WithCutBreakerSleepWindow(5*time.Second),
+		WithCutBreakerOnStateChange(func(name string, from, to State) {
+			changeCh <- [2]State{from, to}
+		}))
+
+	unhealthy := &internal.MetricSummary{Failure: 100, Total: 100, ErrorPercentage: 100, LastExecuteTime: time.Now()}
+	breaker.allow(unhealthy) // Closed→Open。
+	breaker.allow(unhealthy) // 还在休眠期，不变化。
+	breaker.openedAt = time.Now().Add(-10 * time.Second).UnixNano()
+	breaker.allow(unhealthy) // 休眠期已过，Open→HalfOpen。
+	breaker.allow(unhealthy) // 已经是半开状态，不变化。
+	breaker.Success()        // HalfOpen→Closed。
+	breaker.Success()        // 已经关闭，不变化。
+
+	want := [][2]State{
+		{StateClosed, StateOpen},
+		{StateOpen, StateHalfOpen},
+		{StateHalfOpen, StateClosed},
+	}
+	for i, w := range want {
+		select {
+		case got := <-changeCh:
+			if got != w {
+				t.Errorf("OnStateChange #%d got = %v→%v, want %v→%v", i, got[0], got[1], w[0], w[1])
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("OnStateChange #%d not fired", i)
+		}
+	}
+
+	select {
+	case got := <-changeCh:
+		t.Errorf("OnStateChange got unexpected %v→%v", got[0], got[1])
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestCutBreaker_NotifyStateChange 测试注册的监听函数与状态变化回调一样，按顺序收到之后的每次状态变化。
+func TestCutBreaker_NotifyStateChange(t *testing.T) {
+	t.Parallel()
+	breaker := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(5*time.Second),
+		WithCutBreakerMinRequestThreshold(1))
+	breaker.Failure()
+	breaker.Allow() // 注册前的状态变化不通知。
+	breaker.ResetState(false)
+
+	changeCh := make(chan [2]State, 10)
+	breaker.NotifyStateChange(func(name string, from, to State) {
+		changeCh <- [2]State{from, to}
+	})
+	breaker.Allow()
+	breaker.ResetState(true)
+
+	for i, want := range [][2]State{{StateClosed, StateOpen}, {StateOpen, StateClosed}} {
+		select {
+		case got := <-changeCh:
+			if got != want {
+				t.Errorf("NotifyStateChange #%d got = %v→%v, want %v→%v", i, got[0], got[1], want[0], want[1])
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("NotifyStateChange #%d not fired", i)
+		}
+	}
+	select {
+	case got := <-changeCh:
+		t.Errorf("NotifyStateChange got unexpected %v→%v", got[0], got[1])
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestCutBreaker_slowOnStateChange 测试状态变化回调执行缓慢时不会阻塞调用方，也不会丢弃事件。
+func TestCutBreaker_slowOnStateChange(t *testing.T) {
+	t.Parallel()
+	unblock := make(chan struct{})
+	var mu sync.Mutex
+	var got [][2]State
+	breaker := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(5*time.Second),
+		WithCutBreakerMinRequestThreshold(1),
+		WithCutBreakerOnStateChange(func(name string, from, to State) {
+			<-unblock // 回调一直没有返回。
+			mu.Lock()
+			got = append(got, [2]State{from, to})
+			mu.Unlock()
+		}))
+	breaker.Failure()
+
+	const rounds = 50 // 状态变化的数量远大于原来的缓冲区大小。
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < rounds; i++ {
+			breaker.Allow()           // Closed→Open。
+			breaker.ResetState(false) // Open→Closed，保留统计数据，下一次判断重新开启。
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("CutBreaker state changes blocked by a slow OnStateChange")
+	}
+
+	close(unblock)
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(got)
+		mu.Unlock()
+		if n == 2*rounds || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2*rounds {
+		t.Fatalf("OnStateChange calls got = %d, want %d", len(got), 2*rounds)
+	}
+	for i, change := range got {
+		want := [2]State{StateClosed, StateOpen}
+		if i%2 == 1 {
+			want = [2]State{StateOpen, StateClosed}
+		}
+		if change != want {
+			t.Errorf("OnStateChange #%d got = %v→%v, want %v→%v", i, change[0], change[1], want[0], want[1])
+		}
+	}
+}
+
+// TestCutBreaker_onOpenClose 测试开启与关闭回调在每次对应的状态变化时各触发一次，开启回调拿到触发开启的统计数据。
+func TestCutBreaker_onOpenClose(t *testing.T) {
+	t.Parallel()
+	openCh := make(chan *BreakerSummary, 10)
+	closeCh := make(chan string, 10)
+	breaker := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(5*time.Second),
+		WithCutBreakerErrorThresholdPercentage(50),
+		WithCutBreakerMinRequestThreshold(20),
+		WithCutBreakerSleepWindow(5*time.Second),
+		WithCutBreakerOnOpen(func(name string, summary *BreakerSummary) {
+			openCh <- summary
+		}),
+		WithCutBreakerOnClose(func(name string) {
+			closeCh <- name
+		}))
+
+	unhealthy := &internal.MetricSummary{Failure: 80, Success: 20, Total: 100, ErrorPercentage: 80, LastExecuteTime: time.Now()}
+	breaker.allow(unhealthy) // Closed→Open。
+	breaker.allow(unhealthy) // 还在休眠期，不变化。
+	breaker.openedAt = time.Now().Add(-10 * time.Second).UnixNano()
+	breaker.allow(unhealthy) // Open→HalfOpen，不触发。
+	breaker.Failure()        // HalfOpen→Open。
+	breaker.openedAt = time.Now().Add(-10 * time.Second).UnixNano()
+	breaker.allow(unhealthy) // Open→HalfOpen，不触发。
+	breaker.Success()        // HalfOpen→Closed。
+	breaker.Success()        // 已经关闭，不变化。
+
+	waitOpen := func(i int) *BreakerSummary {
+		select {
+		case summary := <-openCh:
+			return summary
+		case <-time.After(time.Second):
+			t.Fatalf("OnOpen #%d not fired", i)
+			return nil
+		}
+	}
+	if summary := waitOpen(0); summary.Name != "test" || summary.Status != "open" || summary.ErrorPercentage != 80 || summary.Total != 100 {
+		t.Errorf("OnOpen #0 summary got = %+v, want the summary that tripped the breaker", summary)
+	}
+	if summary := waitOpen(1); summary == nil || summary.Status != "open" { // 半开探测失败，使用当时的统计数据。
+		t.Errorf("OnOpen #1 summary got = %+v, want open summary", summary)
+	}
+	select {
+	case name := <-closeCh:
+		if name != "test" {
+			t.Errorf("OnClose got = %v, want %v", name, "test")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("OnClose not fired")
+	}
+
+	select {
+	case summary := <-openCh:
+		t.Errorf("OnOpen got unexpected %+v", summary)
+	case name := <-closeCh:
+		t.Errorf("OnClose got unexpected %v", name)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestCutBreaker_slowOnOpenClose 测试开启与关闭回调执行缓慢时不会阻塞调用方，每次开启与关闭都会回调。
+func TestCutBreaker_slowOnOpenClose(t *testing.T) {
+	t.Parallel()
+	unblock := make(chan struct{})
+	var opens, closes int64
+	breaker := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(5*time.Second),
+		WithCutBreakerMinRequestThreshold(1),
+		WithCutBreakerOnOpen(func(name string, summary *BreakerSummary) {
+			<-unblock // 如发送告警很慢。
+			atomic.AddInt64(&opens, 1)
+		}),
+		WithCutBreakerOnClose(func(name string) {
+			atomic.AddInt64(&closes, 1)
+		}))
+	breaker.Failure()
+
+	const rounds = 50
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < rounds; i++ {
+			breaker.Allow()           // Closed→Open。
+			breaker.ResetState(false) // Open→Closed。
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("CutBreaker state changes blocked by a slow OnOpen")
+	}
+
+	close(unblock)
+	deadline := time.Now().Add(time.Second)
+	for (atomic.LoadInt64(&opens) != rounds || atomic.LoadInt64(&closes) != rounds) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := atomic.LoadInt64(&opens); got != rounds {
+		t.Errorf("OnOpen calls got = %d, want %d", got, rounds)
+	}
+	if got := atomic.LoadInt64(&closes); got != rounds {
+		t.Errorf("OnClose calls got = %d, want %d", got, rounds)
+	}
+}
+
+// TestCutBreaker_reset 测试ResetMetrics只清空统计数据，ResetState只重置状态（按参数决定是否清空统计数据）。
+func TestCutBreaker_reset(t *testing.T) {
+	t.Parallel()
+	newOpenBreaker := func() *cutBreaker {
+		breaker := NewCutBreaker("test",
+			WithCutBreakerTimeWindow(5*time.Second),
+			WithCutBreakerMinRequestThreshold(10),
+			WithCutBreakerSleepWindow(5*time.Second))
+		for i := 0; i < 10; i++ {
+			breaker.Failure()
+		}
+		time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+		breaker.Allow()                   // 触发开启。
+		if state := breaker.State(); state != StateOpen {
+			t.Fatalf("CutBreaker.State() got = %v, want %v", state, StateOpen)
+		}
+		return breaker
+	}
+	validate := func(name string, breaker *cutBreaker, state State, failure int64) {
+		if got := breaker.State(); got != state {
+			t.Errorf("%s: CutBreaker.State() got = %v, want %v", name, got, state)
+		}
+		if got := breaker.metric.Summary().Failure; got != failure {
+			t.Errorf("%s: CutBreaker.Summary() Failure got = %d, want %d", name, got, failure)
+		}
+	}
+
+	breaker := newOpenBreaker()
+	breaker.ResetMetrics()
+	validate("ResetMetrics", breaker, StateOpen, 0)
+
+	breaker = newOpenBreaker()
+	breaker.ResetState(false)
+	validate("ResetState(false)", breaker, StateClosed, 10)
+
+	breaker = newOpenBreaker()
+	breaker.ResetState(true)
+	validate("ResetState(true)", breaker, StateClosed, 0)
+	if pass, _ := breaker.Allow(); !pass {
+		t.Errorf("CutBreaker.Allow() after ResetState(true) got = %v, want %v", pass, true)
+	}
+}
+
+// TestCutBreaker_halfOpenMaxRequests 测试半开状态允许多个尝试请求，全部成功才关闭，任意一次失败重新开启。
+func TestCutBreaker_halfOpenMaxRequests(t *testing.T) {
+	t.Parallel()
+	newHalfOpenBreaker := func() *cutBreaker {
+		breaker := NewCutBreaker("test",
+			WithCutBreakerTimeWindow(5*time.Second),
+			WithCutBreakerMinRequestThreshold(20),
+			WithCutBreakerSleepWindow(5*time.Second),
+			WithCutBreakerHalfOpenMaxRequests(3))
+		unhealthy := &internal.MetricSummary{Failure: 100, Total: 100, ErrorPercentage: 100, LastExecuteTime: time.Now()}
+		breaker.allow(unhealthy) // 开启。
+
+		// 休眠期已过。
+		breaker.openedAt = time.Now().Add(-10 * time.Second).UnixNano()
+
+		// 过了休眠时间，只放行3个尝试请求。
+		for i := 0; i < 3; i++ {
+			if pass, statusMsg := breaker.allow(unhealthy); !pass || statusMsg != "half-open" {
+				t.Fatalf("CutBreaker.allow() probe %d got = %v, %v, want %v, %v", i, pass, statusMsg, true, "half-open")
+			}
+		}
+		if pass, _ := breaker.allow(unhealthy); pass {
+			t.Fatalf("CutBreaker.allow() got = %v, want %v", pass, false)
+		}
+		return breaker
+	}
+
+	breaker := newHalfOpenBreaker()
+	breaker.Success()
+	breaker.Success()
+	if state := breaker.State(); state != StateHalfOpen {
+		t.Errorf("CutBreaker.State() after 2 successes got = %v, want %v", state, StateHalfOpen)
+	}
+	breaker.Success()
+	if state := breaker.State(); state != StateClosed {
+		t.Errorf("CutBreaker.State() after 3 successes got = %v, want %v", state, StateClosed)
+	}
+
+	breaker = newHalfOpenBreaker()
+	breaker.Success()
+	breaker.Failure()
+	if state := breaker.State(); state != StateOpen {
+		t.Errorf("CutBreaker.State() after probe failure got = %v, want %v", state, StateOpen)
+	}
+}
+
+// TestCutBreaker_summaryReadOnly 测试读取统计数据不会切换为半开状态，也不会占用半开状态的尝试数量。
+func TestCutBreaker_summaryReadOnly(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(5*time.Second),
+		WithCutBreakerMinRequestThreshold(1),
+		WithCutBreakerSleepWindow(time.Second),
+		WithCutBreakerHalfOpenMaxRequests(1),
+		WithCutBreakerClock(clock))
+	breaker.Failure()
+	if pass, _ := breaker.Allow(); pass {
+		t.Fatalf("CutBreaker.Allow() got = %v, want %v", pass, false)
+	}
+
+	// 休眠时间已过，读取统计数据依然是开启状态。
+	clock.Advance(2 * time.Second)
+	for i := 0; i < 3; i++ {
+		if status := breaker.Summary().Status; status != "open" {
+			t.Errorf("CutBreaker.Summary() Status got = %v, want %v", status, "open")
+		}
+	}
+	if pass, statusMsg := breaker.Allow(); !pass || statusMsg != "half-open" {
+		t.Fatalf("CutBreaker.Allow() got = %v, %v, want %v, %v", pass, statusMsg, true, "half-open")
+	}
+
+	// 半开状态下读取统计数据，不会占用尝试数量，也不会影响探测结果。
+	if status := breaker.Drain().Status; status != "half-open" {
+		t.Errorf("CutBreaker.Drain() Status got = %v, want %v", status, "half-open")
+	}
+	breaker.Success()
+	if state := breaker.State(); state != StateClosed {
+		t.Errorf("CutBreaker.State() got = %v, want %v", state, StateClosed)
+	}
+}
+
+// TestCutBreaker_openedAt 测试休眠时间从开启时刻起算，开启期间的其它统计事件不会推迟进入半开状态。
+func TestCutBreaker_openedAt(t *testing.T) {
+	t.Parallel()
+	breaker := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(5*time.Second),
+		WithCutBreakerMinRequestThreshold(10),
+		WithCutBreakerSleepWindow(300*time.Millisecond))
+	for i := 0; i < 10; i++ {
+		breaker.Failure()
+	}
+	time.Sleep(10 * time.Millisecond) // 确保统计数据已记录完成。
+	if pass, statusMsg := breaker.Allow(); pass || statusMsg != "open" {
+		t.Fatalf("CutBreaker.Allow() got = %v, %v, want %v, %v", pass, statusMsg, false, "open")
+	}
+	openedAt := time.Now()
+
+	// 开启期间持续有降级函数的统计事件，会不断刷新LastExecuteTime。
+	for time.Since(openedAt) < 250*time.Millisecond {
+		breaker.FallbackSuccess()
+		time.Sleep(20 * time.Millisecond)
+	}
+	if pass, statusMsg := breaker.Allow(); pass || statusMsg != "open" {
+		t.Errorf("CutBreaker.Allow() before sleep window got = %v, %v, want %v, %v", pass, statusMsg, false, "open")
+	}
+
+	time.Sleep(300*time.Millisecond - time.Since(openedAt) + 10*time.Millisecond)
+	breaker.FallbackSuccess()
+	if pass, statusMsg := breaker.Allow(); !pass || statusMsg != "half-open" {
+		t.Errorf("CutBreaker.Allow() after sleep window got = %v, %v, want %v, %v", pass, statusMsg, true, "half-open")
+	}
+}
+
+// TestCutBreaker_concurrentTrip 测试并发判断时熔断器开启，休眠时间结束前不会放行任何请求（需要 -race 运行）。
+func TestCutBreaker_concurrentTrip(t *testing.T) {
+	t.Parallel()
+	for round := 0; round < 50; round++ {
+		clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)} // 时间不推进，休眠时间不会结束。
+		breaker := NewCutBreaker("test",
+			WithCutBreakerTimeWindow(5*time.Second),
+			WithCutBreakerMinRequestThreshold(1),
+			WithCutBreakerSleepWindow(time.Second),
+			WithCutBreakerSleepWindowJitter(0.5),
+			WithCutBreakerClock(clock))
+		breaker.Failure() // 下一次判断就会开启。
+
+		var wg sync.WaitGroup
+		var admitted int64
+		start := make(chan struct{})
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				for j := 0; j < 20; j++ {
+					if pass, _ := breaker.Allow(); pass {
+						atomic.AddInt64(&admitted, 1)
+					}
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+		if admitted != 0 {
+			t.Fatalf("round %d: CutBreaker.Allow() admitted %d requests before sleep window, want 0", round, admitted)
+		}
+	}
+}
+
+// TestCutBreaker_warmedUp 测试请求数量达到最小要求后 WarmedUp 变为true，窗口清空后恢复为false。
+func TestCutBreaker_warmedUp(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(time.Second),
+		WithCutBreakerMinRequestThreshold(3),
+		WithCutBreakerClock(clock))
+
+	for i := 0; i < 3; i++ {
+		if summary := b.Summary(); summary.WarmedUp {
+			t.Fatalf("CutBreaker.Summary() WarmedUp with %d requests got = %v, want %v", i, summary.WarmedUp, false)
+		}
+		b.Success()
+	}
+	if summary := b.Summary(); !summary.WarmedUp {
+		t.Errorf("CutBreaker.Summary() WarmedUp got = %v, want %v", summary.WarmedUp, true)
+	}
+
+	clock.Advance(time.Second) // 窗口已经清空。
+	if summary := b.Summary(); summary.WarmedUp {
+		t.Errorf("CutBreaker.Summary() WarmedUp after window got = %v, want %v", summary.WarmedUp, false)
+	}
+}
+
+// TestCutBreaker_recencyWeighting 测试同样的统计数据下，按时间加权时最近的错误高峰会更快开启熔断，而展示的错误百分比不变。
+func TestCutBreaker_recencyWeighting(t *testing.T) {
+	t.Parallel()
+	// 整个窗口的错误百分比未达到阈值，但错误集中在最近的统计块中。
+	summary := &internal.MetricSummary{Success: 15, Failure: 5, Total: 20, ErrorPercentage: 25, WeightedErrorPercentage: 37.5}
+
+	tests := []struct {
+		name             string
+		recencyWeighting bool
+		pass             bool
+	}{
+		{"uniform", false, true},
+		{"recency weighting", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCutBreaker("test",
+				WithCutBreakerTimeWindow(time.Second*3),
+				WithCutBreakerErrorThresholdPercentage(30),
+				WithCutBreakerMinRequestThreshold(10),
+				WithCutBreakerRecencyWeighting(tt.recencyWeighting))
+			if pass, _ := b.allow(summary); pass != tt.pass {
+				t.Errorf("CutBreaker.allow() got = %v, want %v", pass, tt.pass)
+			}
+		})
+	}
+}
+
+func TestCutBreaker_warmup(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(time.Second*5),
+		WithCutBreakerMinRequestThreshold(5),
+		WithCutBreakerWarmup(time.Millisecond*100),
+		WithCutBreakerClock(clock))
+
+	// 预热期间全部失败也不会开启。
+	for i := 0; i < 10; i++ {
+		b.Failure()
+	}
+	if pass, _ := b.Allow(); !pass {
+		t.Errorf("CutBreaker.Allow() during warm-up got = %v, want %v", pass, true)
+	}
+
+	// 预热结束后按错误百分比开启。
+	clock.Advance(time.Millisecond * 100)
+	if pass, _ := b.Allow(); pass {
+		t.Errorf("CutBreaker.Allow() after warm-up got = %v, want %v", pass, false)
+	}
+
+	// Reset后重新预热。
+	b.Reset()
+	for i := 0; i < 10; i++ {
+		b.Failure()
+	}
+	if pass, _ := b.Allow(); !pass {
+		t.Errorf("CutBreaker.Allow() during warm-up after Reset got = %v, want %v", pass, true)
+	}
+}
+
+// TestCutBreaker_slowCall 测试全部调用都成功，但慢调用百分比到达阈值后开启熔断器。
+func TestCutBreaker_slowCall(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		slow int // 10次成功调用中慢调用的数量。
+		pass bool
+	}{
+		{"40% slow", 4, true},
+		{"60% slow", 6, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := NewCutBreaker("test",
+				WithCutBreakerTimeWindow(time.Second*5),
+				WithCutBreakerMinRequestThreshold(10),
+				WithCutBreakerSlowCallDurationThreshold(time.Millisecond*100),
+				WithCutBreakerSlowCallRateThreshold(50))
+			for i := 0; i < 10; i++ {
+				d := time.Millisecond * 10
+				if i < tt.slow {
+					d = time.Millisecond * 200
+				}
+				b.Latency(d)
+				b.SuccessLatency(d)
+				b.Success()
+			}
+
+			summary := b.Summary()
+			if summary.ErrorPercentage != 0 || summary.SlowCall != int64(tt.slow) || summary.SlowCallPercentage != float64(tt.slow*10) {
+				t.Errorf("CutBreaker.Summary() got = %v/%d/%v, want %v/%d/%v",
+					summary.ErrorPercentage, summary.SlowCall, summary.SlowCallPercentage, 0, tt.slow, tt.slow*10)
+			}
+			if pass, _ := b.Allow(); pass != tt.pass {
+				t.Errorf("CutBreaker.Allow() got = %v, want %v", pass, tt.pass)
+			}
+		})
+	}
+}
+
+// TestCutBreaker_slowCallOnlySuccess 测试慢调用只统计成功的执行，慢调用百分比按全部请求计算。
+func TestCutBreaker_slowCallOnlySuccess(t *testing.T) {
+	t.Parallel()
+	b := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(time.Second*5),
+		WithCutBreakerMinRequestThreshold(10),
+		WithCutBreakerErrorThresholdPercentage(80),
+		WithCutBreakerSlowCallDurationThreshold(time.Millisecond*100),
+		WithCutBreakerSlowCallRateThreshold(50))
+	for i := 0; i < 4; i++ { // 慢的成功调用。
+		b.Latency(time.Millisecond * 200)
+		b.SuccessLatency(time.Millisecond * 200)
+		b.Success()
+	}
+	for i := 0; i < 3; i++ { // 慢的失败调用，只计入错误百分比。
+		b.Latency(time.Millisecond * 200)
+		b.Failure()
+	}
+	for i := 0; i < 3; i++ { // 超时的调用，耗时在超时后才记录。
+		b.Timeout()
+		b.Latency(time.Millisecond * 300)
+	}
+
+	summary := b.Summary()
+	if summary.SlowCall != 4 || summary.SlowCallPercentage != 40 || summary.ErrorPercentage != 60 {
+		t.Errorf("CutBreaker.Summary() got = %d/%v/%v, want %d/%v/%v",
+			summary.SlowCall, summary.SlowCallPercentage, summary.ErrorPercentage, 4, 40, 60)
+	}
+	if pass, _ := b.Allow(); !pass {
+		t.Errorf("CutBreaker.Allow() got = %v, want %v", pass, true)
+	}
+}
+
+// TestCutBreaker_halfOpenTimeout 测试尝试请求挂起时，超过探测超时时间后重新开启，之后的休眠周期可以再次尝试。
+func TestCutBreaker_halfOpenTimeout(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(time.Second*5),
+		WithCutBreakerMinRequestThreshold(1),
+		WithCutBreakerSleepWindow(time.Second),
+		WithCutBreakerHalfOpenTimeout(time.Second*2),
+		WithCutBreakerClock(clock))
+
+	b.Failure()
+	validate := func(name string, wantPass bool, wantStatus string) {
+		if pass, status := b.Allow(); pass != wantPass || status != wantStatus {
+			t.Errorf("%s: CutBreaker.Allow() got = %v, %s, want %v, %s", name, pass, status, wantPass, wantStatus)
+		}
+	}
+	validate("tripped", false, "open")
+
+	clock.Advance(time.Second)
+	validate("probe admitted", true, "half-open") // 这个尝试请求一直没有结果。
+	clock.Advance(time.Second)
+	validate("probe pending", false, "half-open")
+	clock.Advance(time.Second)
+	validate("probe timed out", false, "open")
+	if state := b.State(); state != StateOpen {
+		t.Errorf("CutBreaker.State() got = %v, want %v", state, StateOpen)
+	}
+
+	// 下一个休眠周期再次尝试，成功后关闭。
+	clock.Advance(time.Second)
+	validate("probe admitted again", true, "half-open")
+	b.Success()
+	validate("recovered", true, "closed")
+}
+
+// TestCutBreaker_halfOpenConcurrency 测试并发调用 Allow/Success/Failure 时半开状态的约束，需要配合-race运行：
+// 每一轮半开状态最多放行halfOpenMaxRequests个尝试请求；尝试请求全部成功才关闭，任意一个失败都重新开启。
+func TestCutBreaker_halfOpenConcurrency(t *testing.T) {
+	t.Parallel()
+	const maxRequests = 3
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := NewCutBreaker("test",
+		WithCutBreakerTimeWindow(time.Second*5),
+		WithCutBreakerMinRequestThreshold(1),
+		WithCutBreakerSleepWindow(time.Second),
+		WithCutBreakerHalfOpenMaxRequests(maxRequests),
+		WithCutBreakerClock(clock))
+
+	for round := 0; round < 50; round++ {
+		b.Failure()
+		if pass, _ := b.Allow(); pass {
+			t.Fatalf("round %d: CutBreaker.Allow() got = %v, want %v", round, pass, false)
+		}
+		clock.Advance(time.Second) // 进入半开状态。
+
+		fail := round%2 == 1 // 奇数轮第一个尝试请求失败。
+		var probes int64
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pass, status := b.Allow()
+				if !pass || status != "half-open" {
+					return
+				}
+				if atomic.AddInt64(&probes, 1) == 1 && fail {
+					b.Failure()
+				} else {
+					b.Success()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if fail {
+			if probes < 1 || probes > maxRequests {
+				t.Fatalf("round %d: probes got = %d, want 1-%d", round, probes, maxRequests)
+			}
+			if state := b.State(); state != StateOpen {
+				t.Fatalf("round %d: CutBreaker.State() got = %v, want %v", round, state, StateOpen)
+			}
+			b.Reset()
+		} else {
+			if probes != maxRequests {
+				t.Fatalf("round %d: probes got = %d, want %d", round, probes, maxRequests)
+			}
+			if state := b.State(); state != StateClosed {
+				t.Fatalf("round %d: CutBreaker.State() got = %v, want %v", round, state, StateClosed)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package breaker
+
+import "time"
+
+// BreakerDelta 是两次统计摘要之间的变化量，用于监控面板计算每秒请求数、每秒错误数等速率。
+type BreakerDelta struct {
+	Elapsed time.Duration // 两次摘要的时间间隔。
+
+	Success         int64 // 成功数量的变化量。
+	Timeout         int64 // 超时数量的变化量。
+	Failure         int64 // 失败数量的变化量，已经包含了超时。
+	FallbackSuccess int64 // 降级函数执行成功数量的变化量。
+	FallbackFailure int64 // 降级函数执行失败数量的变化量。
+	RequestTotal    int64 // 执行结果总数的变化量。
+
+	RequestRate float64 // 每秒请求数，时间间隔不大于0时为0。
+	SuccessRate float64 // 每秒成功数。
+	FailureRate float64 // 每秒失败数，超时也计入其中。
+}
+
+// Delta 返回本次摘要相对于更早的摘要b的变化量，b为nil时按没有更早的摘要处理，变化量即为本次的累计数量。
+// 变化量通过只增不减的累计数量（Cumulative 开头的字段）计算，滑动窗口的过期、Reset 与 Drain 都不影响结果，
+// 两个摘要需要来自同一个熔断器；累计数量变小（如熔断器被重新创建）时该项按重新开始计数处理，变化量取本次的累计数量。
+func (a *BreakerSummary) Delta(b *BreakerSummary) BreakerDelta {
+	if b == nil {
+		b = &BreakerSummary{SampleTime: a.SampleTime}
+	}
+
+	delta := BreakerDelta{
+		Elapsed:         a.SampleTime.Sub(b.SampleTime),
+		Success:         counterDelta(a.CumulativeSuccess, b.CumulativeSuccess),
+		Timeout:         counterDelta(a.CumulativeTimeout, b.CumulativeTimeout),
+		Failure:         counterDelta(a.CumulativeFailure, b.CumulativeFailure),
+		FallbackSuccess: counterDelta(a.CumulativeFallbackSuccess, b.CumulativeFallbackSuccess),
+		FallbackFailure: counterDelta(a.CumulativeFallbackFailure, b.CumulativeFallbackFailure),
+	}
+	delta.RequestTotal = delta.Success + delta.Failure // Failure中已经包含了超时。
+
+	if seconds := delta.Elapsed.Seconds(); seconds > 0 {
+		delta.RequestRate = float64(delta.RequestTotal) / seconds
+		delta.SuccessRate = float64(delta.Success) / seconds
+		delta.FailureRate = float64(delta.Failure) / seconds
+	}
+	return delta
+}
+
+// counterDelta 返回计数从prev到curr的变化量，计数变小时说明已经重新开始计数，返回curr。
+func counterDelta(curr, prev int64) int64 {
+	if curr < prev {
+		return curr
+	}
+	return curr - prev
+}
@@ -0,0 +1,93 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerSummary_Delta(t *testing.T) {
+	t.Parallel()
+	start := time.Unix(1700000000, 0)
+	prev := &BreakerSummary{CumulativeSuccess: 10, CumulativeFailure: 4, CumulativeTimeout: 2, CumulativeFallbackSuccess: 3, SampleTime: start}
+
+	tests := []struct {
+		name string
+		curr *BreakerSummary
+		prev *BreakerSummary
+		want BreakerDelta
+	}{
+		{
+			"normal",
+			&BreakerSummary{CumulativeSuccess: 30, CumulativeFailure: 14, CumulativeTimeout: 6, CumulativeFallbackSuccess: 8, SampleTime: start.Add(10 * time.Second)},
+			prev,
+			BreakerDelta{Elapsed: 10 * time.Second, Success: 20, Failure: 10, Timeout: 4, FallbackSuccess: 5, RequestTotal: 30,
+				RequestRate: 3, SuccessRate: 2, FailureRate: 1},
+		},
+		{
+			"windowCountsIgnored", // 窗口内的数量变小不影响变化量，只使用累计数量。
+			&BreakerSummary{Success: 1, RequestTotal: 1, CumulativeSuccess: 14, CumulativeFailure: 5, CumulativeTimeout: 2, CumulativeFallbackSuccess: 3, SampleTime: start.Add(5 * time.Second)},
+			prev,
+			BreakerDelta{Elapsed: 5 * time.Second, Success: 4, Failure: 1, Timeout: 0, FallbackSuccess: 0, RequestTotal: 5,
+				RequestRate: 1, SuccessRate: 0.8, FailureRate: 0.2},
+		},
+		{
+			"recreated", // 累计数量变小（熔断器被重新创建）时按重新开始计数。
+			&BreakerSummary{CumulativeSuccess: 4, CumulativeFailure: 1, SampleTime: start.Add(5 * time.Second)},
+			prev,
+			BreakerDelta{Elapsed: 5 * time.Second, Success: 4, Failure: 1, Timeout: 0, FallbackSuccess: 0, RequestTotal: 5,
+				RequestRate: 1, SuccessRate: 0.8, FailureRate: 0.2},
+		},
+		{
+			"noPrevious",
+			&BreakerSummary{CumulativeSuccess: 4, SampleTime: start},
+			nil,
+			BreakerDelta{Success: 4, RequestTotal: 4},
+		},
+		{
+			"sameSampleTime", // 间隔为0时不计算速率。
+			&BreakerSummary{CumulativeSuccess: 12, SampleTime: start},
+			&BreakerSummary{CumulativeSuccess: 10, SampleTime: start},
+			BreakerDelta{Success: 2, RequestTotal: 2},
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.curr.Delta(tt.prev); got != tt.want {
+			t.Errorf("%s: BreakerSummary.Delta() got = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBreakerSummary_DeltaWindowSlides(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := NewCutBreaker("test", WithCutBreakerTimeWindow(5*time.Second), WithCutBreakerClock(clock))
+
+	for i := 0; i < 10; i++ {
+		b.Success()
+	}
+	b.Failure()
+	prev := b.Summary()
+
+	clock.Advance(10 * time.Second) // 两次采样之间滑动窗口中的数据全部过期。
+	for i := 0; i < 3; i++ {
+		b.Success()
+	}
+	b.Timeout()
+	curr := b.Summary()
+
+	if curr.Success >= prev.Success {
+		t.Fatalf("window Success got = %d, want less than %d", curr.Success, prev.Success)
+	}
+	want := BreakerDelta{Elapsed: 10 * time.Second, Success: 3, Failure: 1, Timeout: 1, RequestTotal: 4,
+		RequestRate: 0.4, SuccessRate: 0.3, FailureRate: 0.1}
+	if got := curr.Delta(prev); got != want {
+		t.Errorf("BreakerSummary.Delta() got = %+v, want %+v", got, want)
+	}
+
+	b.Drain()
+	b.Reset() // Drain 与 Reset 不影响累计数量。
+	b.Success()
+	if got := b.Summary().Delta(curr).Success; got != 1 {
+		t.Errorf("BreakerSummary.Delta() after Reset Success got = %d, want 1", got)
+	}
+}
@@ -0,0 +1,301 @@
+package breaker
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bunnier/circuit/breaker/internal"
+)
+
+var _ Breaker = (*gradientBreaker)(nil)
+var _ Configurable = (*gradientBreaker)(nil)
+var _ Releaser = (*gradientBreaker)(nil)
+
+// gradientBreaker 是 Breaker 的一种实现。
+type gradientBreaker struct {
+	name   string           // 名称。
+	metric *internal.Metric // 执行情况统计数据。
+
+	forcedStatus int32 // 手动强制状态，优先于并发数判断。
+
+	inFlight int64 // 已放行但还没有记录耗时的请求数量。
+	limit    int64 // 当前允许的最大并发数，由estimatedLimit取整得到，需要原子操作。
+
+	lock           sync.Mutex       // 用于控制以下字段的并发。
+	estimatedLimit float64          // 估算出的并发数上限。
+	minRTT         [2]time.Duration // 滚动最小耗时，分别为当前周期与上一个周期的最小值，0表示没有数据。
+	rttPeriodStart time.Time        // 当前周期的开始时间。
+
+	initialLimit int64         // 初始并发数上限。
+	minLimit     int64         // 并发数上限的最小值。
+	maxLimit     int64         // 并发数上限的最大值。
+	tolerance    float64       // 耗时达到最小耗时的多少倍后开始降低并发数上限。
+	smoothing    float64       // 每次调整时新估算值所占的比例（0-1）。
+	rttWindow    time.Duration // 滚动最小耗时的周期，最小耗时取最近两个周期中的最小值。
+	timeWindow   time.Duration // 滑动窗口的大小（单位秒1-60）。
+}
+
+// NewGradientBreaker 用于新建一个 GradientBreaker 熔断器。
+// GradientBreaker 是参考 Netflix concurrency-limits gradient 算法的自适应并发限制器，并发数超过当前上限时拒绝请求。
+// 每次记录耗时时，用滚动最小耗时（近似无负载时的耗时）与本次耗时的比值作为梯度，调整并发数上限：
+// gradient = clamp(tolerance*minRTT/rtt, 0.5, 1)，newLimit = limit*gradient + sqrt(limit)，再按smoothing平滑。
+// 耗时明显变长时上限随之降低，耗时恢复后逐步增长；实际并发数不到上限一半时不增长，以免空闲时上限无限变大。
+// 注意：每次 Allow 放行都会占用一个并发数，执行后通过 Latency 归还；放行后没有执行的请求需要调用 Release 归还（见 Releaser）。
+// 通过 Command 使用时两者都会自动调用，可以与 WithCommandMaxConcurrency 等同时使用；单独使用时需要自行保证每次放行都调用其中之一。
+func NewGradientBreaker(name string, options ...GradientBreakerOption) *gradientBreaker {
+	b := &gradientBreaker{
+		name:         name,
+		initialLimit: 20,
+		minLimit:     1,
+		maxLimit:     200,
+		tolerance:    2, // 耗时达到最小耗时的2倍后开始降低。
+		smoothing:    0.2,
+		rttWindow:    time.Minute,
+		timeWindow:   time.Second * 5,
+	}
+
+	for _, option := range options {
+		option(b)
+	}
+
+	// 初始化选项后，根据选项初始化Metric。
+	b.metric = internal.NewMetric(
+		internal.WithMetricTimeWindow(b.timeWindow),
+	)
+	b.resetLimit()
+
+	return b
+}
+
+// resetLimit 用于将并发数上限与滚动最小耗时恢复为初始状态。
+func (b *gradientBreaker) resetLimit() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.estimatedLimit = float64(b.initialLimit)
+	b.minRTT = [2]time.Duration{}
+	b.rttPeriodStart = time.Now()
+	atomic.StoreInt64(&b.limit, b.initialLimit)
+}
+
+// Allow 用于判断断路器是否允许通过请求，并发数没有超过当前上限时放行。
+// 第一返回值：true能通过/false不能；第二返回值：当前Breaker状态的文字描述。
+func (b *gradientBreaker) Allow() (bool, string) {
+	if pass, statusMsg, forced := forcedAllow(&b.forcedStatus); forced {
+		if pass { // 强制放行的请求同样会记录耗时，需要计入并发数。
+			atomic.AddInt64(&b.inFlight, 1)
+		}
+		return pass, statusMsg // 手动强制状态优先于自动判断。
+	}
+
+	limit := atomic.LoadInt64(&b.limit)
+	if inFlight := atomic.AddInt64(&b.inFlight, 1); inFlight > limit {
+		atomic.AddInt64(&b.inFlight, -1)
+		return false, b.status(inFlight-1, limit)
+	}
+	return true, b.status(atomic.LoadInt64(&b.inFlight), limit)
+}
+
+// status 返回当前状态的文字描述，直接显示并发数与上限。
+func (b *gradientBreaker) status(inFlight, limit int64) string {
+	if _, statusMsg, forced := forcedAllow(&b.forcedStatus); forced {
+		return statusMsg
+	}
+	return fmt.Sprintf("in-flight = %d, limit = %d", inFlight, limit)
+}
+
+// Success 用于记录成功事件。
+func (b *gradientBreaker) Success() {
+	b.metric.Success()
+}
+
+// Failure 用于记录失败事件。
+func (b *gradientBreaker) Failure() {
+	b.metric.Failure()
+}
+
+// Timeout 用于记录超时事件。
+func (b *gradientBreaker) Timeout() {
+	b.metric.Timeout()
+}
+
+// Release 用于释放一个已放行但没有执行的请求占用的并发数，不调整并发数上限。
+func (b *gradientBreaker) Release() {
+	b.release()
+}
+
+// Latency 记录一次功能函数执行耗时，释放一个并发数，并根据耗时调整并发数上限。
+func (b *gradientBreaker) Latency(d time.Duration) {
+	inFlight := b.release()
+	b.metric.Latency(d)
+	if d <= 0 {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	minRTT := b.updateMinRTT(d)
+	gradient := math.Max(0.5, math.Min(1, b.tolerance*float64(minRTT)/float64(d)))
+	if gradient == 1 && float64(inFlight) < b.estimatedLimit/2 {
+		return // 耗时正常但并发数远没有达到上限，说明流量不足，不增长上限。
+	}
+
+	newLimit := b.estimatedLimit*gradient + math.Sqrt(b.estimatedLimit)
+	newLimit = b.estimatedLimit*(1-b.smoothing) + newLimit*b.smoothing
+	newLimit = math.Max(float64(b.minLimit), math.Min(float64(b.maxLimit), newLimit))
+
+	b.estimatedLimit = newLimit
+	atomic.StoreInt64(&b.limit, int64(newLimit))
+}
+
+// release 用于释放一个并发数，返回释放前的并发数，不会小于0。
+func (b *gradientBreaker) release() int64 {
+	for {
+		inFlight := atomic.LoadInt64(&b.inFlight)
+		if inFlight <= 0 {
+			return 0
+		}
+		if atomic.CompareAndSwapInt64(&b.inFlight, inFlight, inFlight-1) {
+			return inFlight
+		}
+	}
+}
+
+// updateMinRTT 用于记录一次耗时，并返回最近两个周期中的最小耗时，调用方需要持有锁。
+func (b *gradientBreaker) updateMinRTT(d time.Duration) time.Duration {
+	if time.Since(b.rttPeriodStart) >= b.rttWindow { // 进入新的周期，丢弃更早周期的数据，以便下游恢复后能重新找到最小耗时。
+		b.minRTT[1], b.minRTT[0] = b.minRTT[0], 0
+		b.rttPeriodStart = time.Now()
+	}
+	if b.minRTT[0] == 0 || d < b.minRTT[0] {
+		b.minRTT[0] = d
+	}
+	if b.minRTT[1] != 0 && b.minRTT[1] < b.minRTT[0] {
+		return b.minRTT[1]
+	}
+	return b.minRTT[0]
+}
+
+// FallbackSuccess 记录一次降级函数执行成功事件。
+func (b *gradientBreaker) FallbackSuccess() {
+	b.metric.FallbackSuccess()
+}
+
+// FallbackFailure 记录一次降级函数执行失败事件。
+func (b *gradientBreaker) FallbackFailure() {
+	b.metric.FallbackFailure()
+}
+
+// Summary 返回当前健康状态。
+func (b *gradientBreaker) Summary() *BreakerSummary {
+	statusStr := b.status(atomic.LoadInt64(&b.inFlight), atomic.LoadInt64(&b.limit))
+	return newBreakerSummary(b.name, statusStr, b.metric.Summary(), 1)
+}
+
+// Drain 返回当前健康状态，并同时重置上报用的统计数据。
+func (b *gradientBreaker) Drain() *BreakerSummary {
+	statusStr := b.status(atomic.LoadInt64(&b.inFlight), atomic.LoadInt64(&b.limit))
+	return newBreakerSummary(b.name, statusStr, b.metric.Drain(), 1)
+}
+
+// LatencyBuckets 返回滑动窗口中按时间分布的耗时直方图。
+func (b *gradientBreaker) LatencyBuckets() [][]int64 {
+	return b.metric.LatencyBuckets()
+}
+
+// State 返回熔断器当前状态，并发数达到上限时视为开启。
+func (b *gradientBreaker) State() State {
+	if isForced(&b.forcedStatus) {
+		return StateForced
+	}
+	if atomic.LoadInt64(&b.inFlight) >= atomic.LoadInt64(&b.limit) {
+		return StateOpen
+	}
+	return StateClosed
+}
+
+// Reset 用于清空统计数据，并将并发数上限恢复为初始值，不影响正在执行的请求。
+func (b *gradientBreaker) Reset() {
+	b.metric.Reset()
+	b.resetLimit()
+}
+
+// Config 返回熔断器的有效配置。
+func (b *gradientBreaker) Config() BreakerConfig {
+	return BreakerConfig{
+		Type: "gradient",
+		Options: map[string]interface{}{
+			"timeWindow":   b.timeWindow,
+			"initialLimit": b.initialLimit,
+			"minLimit":     b.minLimit,
+			"maxLimit":     b.maxLimit,
+			"tolerance":    b.tolerance,
+			"smoothing":    b.smoothing,
+			"rttWindow":    b.rttWindow,
+		},
+	}
+}
+
+// ForceOpen 用于手动强制开启熔断器，强制期间拒绝所有请求。
+func (b *gradientBreaker) ForceOpen() {
+	atomic.StoreInt32(&b.forcedStatus, forcedOpen)
+}
+
+// ForceClose 用于手动强制关闭熔断器，强制期间放行所有请求。
+func (b *gradientBreaker) ForceClose() {
+	atomic.StoreInt32(&b.forcedStatus, forcedClosed)
+}
+
+// ClearForced 用于清除手动强制状态，恢复熔断器的自动判断。
+func (b *gradientBreaker) ClearForced() {
+	atomic.StoreInt32(&b.forcedStatus, forcedNone)
+}
+
+// GradientBreakerOption 是 GradientBreaker 的可选项。
+type GradientBreakerOption func(b *gradientBreaker)
+
+// WithGradientBreakerInitialLimit 设置初始并发数上限（默认20）。
+func WithGradientBreakerInitialLimit(limit int64) GradientBreakerOption {
+	return func(b *gradientBreaker) {
+		b.initialLimit = limit
+	}
+}
+
+// WithGradientBreakerLimitRange 设置并发数上限的调整范围（默认1-200）。
+func WithGradientBreakerLimitRange(minLimit, maxLimit int64) GradientBreakerOption {
+	return func(b *gradientBreaker) {
+		b.minLimit = minLimit
+		b.maxLimit = maxLimit
+	}
+}
+
+// WithGradientBreakerTolerance 设置耗时达到最小耗时的多少倍后开始降低并发数上限（默认2）。
+func WithGradientBreakerTolerance(tolerance float64) GradientBreakerOption {
+	return func(b *gradientBreaker) {
+		b.tolerance = tolerance
+	}
+}
+
+// WithGradientBreakerSmoothing 设置每次调整时新估算值所占的比例（默认0.2），越大调整越快。
+func WithGradientBreakerSmoothing(smoothing float64) GradientBreakerOption {
+	return func(b *gradientBreaker) {
+		b.smoothing = smoothing
+	}
+}
+
+// WithGradientBreakerRTTWindow 设置滚动最小耗时的周期（默认1分钟），最小耗时取最近两个周期中的最小值。
+func WithGradientBreakerRTTWindow(rttWindow time.Duration) GradientBreakerOption {
+	return func(b *gradientBreaker) {
+		b.rttWindow = rttWindow
+	}
+}
+
+// WithGradientBreakerTimeWindow 设置统计数据滑动窗口的大小（要求1-60s）。
+func WithGradientBreakerTimeWindow(timeWindow time.Duration) GradientBreakerOption {
+	return func(b *gradientBreaker) {
+		b.timeWindow = timeWindow
+	}
+}
@@ -0,0 +1,94 @@
+package breaker
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGradientBreaker_Allow(t *testing.T) {
+	t.Parallel()
+	b := NewGradientBreaker("test", WithGradientBreakerInitialLimit(2))
+
+	// 并发数达到上限后拒绝。
+	for i := 0; i < 2; i++ {
+		if pass, _ := b.Allow(); !pass {
+			t.Fatalf("GradientBreaker.Allow() got = %v, want %v", pass, true)
+		}
+	}
+	if pass, status := b.Allow(); pass || status != "in-flight = 2, limit = 2" {
+		t.Errorf("GradientBreaker.Allow() got = %v, %s, want %v, %s", pass, status, false, "in-flight = 2, limit = 2")
+	}
+	if state := b.State(); state != StateOpen {
+		t.Errorf("GradientBreaker.State() got = %v, want %v", state, StateOpen)
+	}
+
+	// 记录耗时后释放并发数。
+	b.Latency(time.Millisecond)
+	if pass, _ := b.Allow(); !pass {
+		t.Errorf("GradientBreaker.Allow() after Latency got = %v, want %v", pass, true)
+	}
+
+	// 手动强制状态优先。
+	b.ForceClose()
+	if pass, _ := b.Allow(); !pass {
+		t.Errorf("GradientBreaker.Allow() forced closed got = %v, want %v", pass, true)
+	}
+	b.ForceOpen()
+	if pass, status := b.Allow(); pass || status != "forced-open" {
+		t.Errorf("GradientBreaker.Allow() forced open got = %v, %s, want %v, %s", pass, status, false, "forced-open")
+	}
+}
+
+// TestGradientBreaker_limit 测试耗时变长时降低并发数上限，耗时恢复后逐步增长。
+func TestGradientBreaker_limit(t *testing.T) {
+	t.Parallel()
+	b := NewGradientBreaker("test", WithGradientBreakerInitialLimit(20))
+
+	// simulate 保持8个并发请求，并以指定耗时完成n次。
+	simulate := func(rtt time.Duration, n int) {
+		for atomic.LoadInt64(&b.inFlight) < 8 {
+			b.Allow()
+		}
+		for i := 0; i < n; i++ {
+			b.Latency(rtt)
+			b.Success()
+			b.Allow()
+		}
+	}
+
+	// 耗时稳定在10ms，并发数没有到上限的一半，上限不变。
+	simulate(time.Millisecond*10, 50)
+	if limit := atomic.LoadInt64(&b.limit); limit != 20 {
+		t.Errorf("limit with idle traffic got = %d, want %d", limit, 20)
+	}
+
+	// 耗时变为10倍，上限降低，收敛到 limit = 0.5*limit + sqrt(limit) 即4附近。
+	simulate(time.Millisecond*100, 100)
+	if limit := atomic.LoadInt64(&b.limit); limit < 3 || limit > 5 {
+		t.Errorf("limit with slow calls got = %d, want about %d", limit, 4)
+	}
+	if pass, _ := b.Allow(); pass { // 并发数已经超过新的上限。
+		t.Errorf("GradientBreaker.Allow() got = %v, want %v", pass, false)
+	}
+
+	// 耗时恢复后，并发数达到上限，上限逐步增长。
+	before := atomic.LoadInt64(&b.limit)
+	for i := 0; i < 50; i++ {
+		b.Latency(time.Millisecond * 10)
+		for {
+			if pass, _ := b.Allow(); !pass {
+				break
+			}
+		}
+	}
+	if limit := atomic.LoadInt64(&b.limit); limit <= before {
+		t.Errorf("limit after recovery got = %d, want > %d", limit, before)
+	}
+
+	// Reset后恢复初始上限。
+	b.Reset()
+	if limit := atomic.LoadInt64(&b.limit); limit != 20 {
+		t.Errorf("limit after Reset got = %d, want %d", limit, 20)
+	}
+}
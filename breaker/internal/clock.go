@@ -0,0 +1,15 @@
+package internal
+
+import "time"
+
+// Clock 是统计数据使用的时间源，默认使用系统时间，测试时可以替换为可控的实现。
+type Clock interface {
+	Now() time.Time // 返回当前时间。
+}
+
+// realClock 是使用系统时间的 Clock 实现。
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
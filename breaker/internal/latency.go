@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets 是耗时直方图各个桶的上限（含），超过最后一个上限的耗时计入最后的溢出桶。
+var latencyBuckets = [...]time.Duration{
+	time.Millisecond,
+	time.Millisecond * 5,
+	time.Millisecond * 10,
+	time.Millisecond * 25,
+	time.Millisecond * 50,
+	time.Millisecond * 100,
+	time.Millisecond * 250,
+	time.Millisecond * 500,
+	time.Second,
+	time.Millisecond * 2500,
+	time.Second * 5,
+	time.Second * 10,
+}
+
+// LatencyBucketBounds 返回耗时直方图各个桶的上限（含），最后还有一个没有上限的溢出桶。
+func LatencyBucketBounds() []time.Duration {
+	bounds := make([]time.Duration, len(latencyBuckets))
+	copy(bounds, latencyBuckets[:])
+	return bounds
+}
+
+// latencyBucketCount 是直方图桶的数量，多出的一个为溢出桶。
+const latencyBucketCount = len(latencyBuckets) + 1
+
+// LatencyHistogram 用于按固定的桶记录耗时分布。
+type LatencyHistogram struct {
+	Count   int64                     // 记录的次数。
+	Sum     time.Duration             // 累计耗时。
+	Max     time.Duration             // 最大耗时。
+	Buckets [latencyBucketCount]int64 // 各个桶的次数。
+}
+
+// Record 用于记录一次耗时，可以并发调用。
+func (h *LatencyHistogram) Record(d time.Duration) {
+	atomic.AddInt64(&h.Count, 1)
+	atomic.AddInt64((*int64)(&h.Sum), int64(d))
+	for {
+		max := atomic.LoadInt64((*int64)(&h.Max))
+		if int64(d) <= max || atomic.CompareAndSwapInt64((*int64)(&h.Max), max, int64(d)) {
+			break
+		}
+	}
+
+	index := len(latencyBuckets) // 默认计入溢出桶。
+	for i, upper := range latencyBuckets {
+		if d <= upper {
+			index = i
+			break
+		}
+	}
+	atomic.AddInt64(&h.Buckets[index], 1)
+}
+
+// Merge 用于将另一个直方图的数据累加到当前直方图，另一个直方图可以正在被并发记录。
+func (h *LatencyHistogram) Merge(other *LatencyHistogram) {
+	h.Count += atomic.LoadInt64(&other.Count)
+	h.Sum += time.Duration(atomic.LoadInt64((*int64)(&other.Sum)))
+	if max := time.Duration(atomic.LoadInt64((*int64)(&other.Max))); max > h.Max {
+		h.Max = max
+	}
+	for i := range other.Buckets {
+		h.Buckets[i] += atomic.LoadInt64(&other.Buckets[i])
+	}
+}
+
+// Mean 返回平均耗时，没有数据时返回0。
+func (h *LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Percentile 返回指定百分位（0-100）的耗时，没有数据时返回0。
+// 结果为所在桶的上限，不会超过记录到的最大耗时，落在溢出桶时返回最大耗时。
+func (h *LatencyHistogram) Percentile(p float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+
+	target := int64(math.Ceil(float64(h.Count) * p / 100)) // 需要覆盖的次数。
+	if target < 1 {
+		target = 1
+	}
+
+	var cumulative int64
+	for i, count := range h.Buckets {
+		cumulative += count
+		if cumulative < target {
+			continue
+		}
+		if i < len(latencyBuckets) && latencyBuckets[i] < h.Max {
+			return latencyBuckets[i]
+		}
+		return h.Max
+	}
+	return h.Max
+}
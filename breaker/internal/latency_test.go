@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram_Percentile(t *testing.T) {
+	t.Parallel()
+	var h LatencyHistogram
+	for i := 1; i <= 100; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{1, time.Millisecond},
+		{5, time.Millisecond * 5},
+		{50, time.Millisecond * 50},
+		{51, time.Millisecond * 100}, // 51ms落在100ms的桶中。
+		{99, time.Millisecond * 100}, // 不会超过最大耗时。
+	}
+	for _, tt := range tests {
+		if got := h.Percentile(tt.p); got != tt.want {
+			t.Errorf("LatencyHistogram.Percentile(%v) got = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+
+	// 溢出桶返回最大耗时。
+	h.Record(time.Minute)
+	if got := h.Percentile(100); got != time.Minute {
+		t.Errorf("LatencyHistogram.Percentile(100) got = %v, want %v", got, time.Minute)
+	}
+}
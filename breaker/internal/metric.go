@@ -1,58 +1,54 @@
 package internal
 
 import (
-	"context"
 	"math"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Metric 用于保存Command的运行情况统计数据。
-// 内部使用滑动窗口方式存储统计数据。
+// 内部使用滑动窗口方式存储统计数据，记录事件时直接原子累加到当前时间所在的统计块，不需要额外的统计goroutine。
 type Metric struct {
-	ctx context.Context // 用于释放资源的context。
+	clock Clock // 时间源。
+
+	// 记录事件与获取摘要时持有读锁，统计块切换区间、重置与Drain时持有写锁。
+	lock sync.RWMutex
 
 	timeWindow     time.Duration // 滑动窗口的大小。
 	metricInterval time.Duration // 窗口中每个统计量的间隔区间。
-
-	counters []*UnitCounter // 滑动窗口的所有统计数据，按timeWindow的秒数，多少秒就多少长度。
-
-	successCh         chan time.Time // 用于记录一次成功数量统计。
-	timeoutCh         chan time.Time // 用于记录一次超时数量统计
-	failureCh         chan time.Time // 用于记录一次失败数量统计。
-	fallbackSuccessCh chan time.Time // 用于记录一次降级函数执行成功统计。
-	fallbackFailureCh chan time.Time // 用于记录一次降级函数执行失败统计。
-
-	resetCh chan time.Time // 用于重置所有统计数据。
-
-	makeSummaryCh chan struct{}       // 用于计算统计数据。
-	getSummaryCh  chan *MetricSummary // 用于获取统计数据。
-
-	lastExecuteTime time.Time // 最后一次执行时间。
-	lastSuccessTime time.Time // 最后一次成功执行时间。
-	lastTimeoutTime time.Time // 最后一次超时时间。
-	lastFailureTime time.Time // 最后一次失败时间。
-	lastResetTime   time.Time // 最后一次重置统计时间。
+	bucketCount    int           // 窗口中统计量的数量，大于0时按窗口大小均分间隔区间，优先于metricInterval。
+	bucketDuration time.Duration // 窗口中每个统计量的间隔区间，可以小于1秒，大于0时优先于bucketCount与metricInterval。
+
+	counters []*UnitCounter // 滑动窗口的所有统计数据，按区间序号取模组成环。
+	report   UnitCounter    // 上一次Drain以来的统计数据，用于按周期上报，与滑动窗口相互独立，不影响熔断器的判断。
+	totals   UnitCounter    // 自创建以来的累计数量，只增不减，只使用成功、失败等计数字段。
+
+	// 以下时间均为UnixNano，0表示没有记录，需要原子操作。
+	lastExecuteTime int64 // 最后一次执行时间。
+	lastSuccessTime int64 // 最后一次成功执行时间。
+	lastTimeoutTime int64 // 最后一次超时时间。
+	lastFailureTime int64 // 最后一次失败时间。
+	lastResetTime   int64 // 最后一次重置统计时间。
 }
 
-// UnitCounter 用于记录滑动窗口中一个单元（1s）的统计数据。
+// UnitCounter 用于记录滑动窗口中一个统计区间的统计数据，各个字段需要原子操作。
 type UnitCounter struct {
 	Success         int64 // 成功数量。
 	Timeout         int64 // 超时数量。
 	Failure         int64 // 失败数量。
 	FallbackSuccess int64 // 降级函数执行成功数量。
 	FallbackFailure int64 // 降级函数执行失败数量。
+	SlowCall        int64 // 慢调用数量。
+
+	Latency LatencyHistogram // 功能函数执行耗时分布。
 
-	LastRecordTime time.Time // 记录最后一次写入的时间。
+	slot int64 // 统计块当前所属的区间序号。
 }
 
-// Reset 用于重置统计量。
+// Reset 用于重置统计量，调用方需要保证没有并发写入。
 func (counter *UnitCounter) Reset() {
-	counter.Success = 0
-	counter.Timeout = 0
-	counter.Failure = 0
-	counter.FallbackSuccess = 0
-	counter.FallbackFailure = 0
-	counter.LastRecordTime = time.Time{}
+	*counter = UnitCounter{}
 }
 
 // MetricSummary 返回统计数据摘要。
@@ -62,40 +58,60 @@ type MetricSummary struct {
 
 	Success         int64 // 成功数量。
 	Timeout         int64 // 超时数量。
-	Failure         int64 // 失败数量。
+	Failure         int64 // 失败数量，已经包含了超时数量，即 Failure = 非超时的失败数量 + Timeout。
 	FallbackSuccess int64 // 降级函数执行成功数量。
 	FallbackFailure int64 // 降级函数执行失败数量。
 
-	Total           int64   // 本次统计窗口所执行的所有次数。
-	ErrorPercentage float64 // 错误数量百分比。
+	// 本次统计窗口功能函数的执行结果总数，即 Success + Failure，超时只计算一次，不包含降级函数的执行结果。
+	RequestTotal    int64
+	Total           int64   // 与 RequestTotal 相同，为兼容保留。
+	ErrorPercentage float64 // 错误数量百分比，即 Failure / RequestTotal，超时也计入其中。
+
+	// 按时间线性加权的错误数量百分比，最新的统计块权重为统计块数量，每早一个区间权重减1，最早的为1。
+	WeightedErrorPercentage float64
+
+	SlowCall           int64   // 慢调用数量，由调用方根据耗时判断后通过 SlowCall 记录。
+	SlowCallPercentage float64 // 慢调用百分比，即 SlowCall / RequestTotal。
+
+	MeanLatency time.Duration // 功能函数平均耗时。
+	P99Latency  time.Duration // 功能函数99分位耗时（按直方图桶的上限估算）。
 
 	LastExecuteTime time.Time // 最后一次执行时间。
 	LastSuccessTime time.Time // 最后一次成功执行时间。
 	LastTimeoutTime time.Time // 最后一次超时时间。
 	LastFailureTime time.Time // 最后一次失败时间。
+
+	SampleTime time.Time // 生成摘要的时间。
+
+	// 以下为自创建以来的累计数量，只增不减，不受滑动窗口、Reset 与 Drain 的影响，用于计算两次摘要之间的变化量。
+	CumulativeSuccess         int64 // 累计成功数量。
+	CumulativeTimeout         int64 // 累计超时数量。
+	CumulativeFailure         int64 // 累计失败数量，已经包含了超时数量。
+	CumulativeFallbackSuccess int64 // 累计降级函数执行成功数量。
+	CumulativeFallbackFailure int64 // 累计降级函数执行失败数量。
 }
 
 // NewMetric 用于获取一个Metric对象。
 func NewMetric(options ...MerticOption) *Metric {
-	const channelBufferSize int8 = 10 // 用于发送统计数据的channel大小。
 	m := &Metric{
-		ctx:               context.Background(),
-		timeWindow:        time.Second * 5, // 滑动窗口的大小。
-		metricInterval:    time.Second,     // 窗口中每个统计量的间隔区间。
-		successCh:         make(chan time.Time, channelBufferSize),
-		timeoutCh:         make(chan time.Time, channelBufferSize),
-		failureCh:         make(chan time.Time, channelBufferSize),
-		fallbackSuccessCh: make(chan time.Time, channelBufferSize),
-		fallbackFailureCh: make(chan time.Time, channelBufferSize),
-		resetCh:           make(chan time.Time, channelBufferSize),
-		makeSummaryCh:     make(chan struct{}, channelBufferSize),
-		getSummaryCh:      make(chan *MetricSummary, channelBufferSize),
+		clock:          realClock{},
+		timeWindow:     time.Second * 5, // 滑动窗口的大小。
+		metricInterval: time.Second,     // 窗口中每个统计量的间隔区间。
 	}
 
 	for _, option := range options {
 		option(m)
 	}
 
+	if m.bucketDuration > 0 { // 直接指定了间隔区间时，窗口大小必须是它的整数倍。
+		if m.timeWindow%m.bucketDuration != 0 {
+			panic("metric: timeWindow must be a multiple of bucketDuration")
+		}
+		m.metricInterval = m.bucketDuration
+	} else if m.bucketCount > 0 { // 指定了统计量数量时，间隔区间由窗口大小均分得到。
+		m.metricInterval = m.timeWindow / time.Duration(m.bucketCount)
+	}
+
 	if m.timeWindow < m.metricInterval { // 统计间隔不能大于整个窗口。
 		panic("metric: metricInterval must be equal or less than timeWindow")
 	}
@@ -103,165 +119,330 @@ func NewMetric(options ...MerticOption) *Metric {
 	// 根据窗口大小初始化统计切片。
 	counterLen := int(math.Ceil(float64(m.timeWindow) / float64(m.metricInterval)))
 	m.counters = make([]*UnitCounter, counterLen)
-
-	// 开始接收统计。
-	m.run()
+	for i := range m.counters {
+		m.counters[i] = &UnitCounter{}
+	}
 
 	return m
 }
 
-func (m *Metric) makeSummary() {
+// makeSummary 根据当前统计块计算统计摘要，调用方需要持有锁。
+func (m *Metric) makeSummary() *MetricSummary {
 	summary := MetricSummary{}
+	var histogram LatencyHistogram
+	now := m.clock.Now()
+	current := m.slot(now)
 
+	var weightedTotal, weightedFailure float64
 	for _, counter := range m.counters {
-		if counter == nil {
+		// 如果调用不连续，统计块可能有一些不属于本次窗口，所以需要一一判断区间序号。
+		slot := atomic.LoadInt64(&counter.slot)
+		if !m.inWindow(current, slot) {
 			continue
 		}
 
-		// 如果调用不连续，统计块可能有一些不属于本次窗口，所以需要一一判断时间。
-		if time.Since(counter.LastRecordTime) > m.timeWindow {
-			continue
-		}
-
-		summary.Success += counter.Success
-		summary.Timeout += counter.Timeout
-		summary.Failure += counter.Failure
-		summary.FallbackSuccess += counter.FallbackSuccess
-		summary.FallbackFailure += counter.FallbackFailure
+		success := atomic.LoadInt64(&counter.Success)
+		timeout := atomic.LoadInt64(&counter.Timeout) // 先读超时再读失败，与Timeout的写入顺序相反。
+		failure := atomic.LoadInt64(&counter.Failure)
+		weight := float64(int64(len(m.counters)) - (current - slot))
+		weightedTotal += weight * float64(success+failure)
+		weightedFailure += weight * float64(failure)
+
+		summary.Success += success
+		summary.Timeout += timeout
+		summary.Failure += failure
+		summary.FallbackSuccess += atomic.LoadInt64(&counter.FallbackSuccess)
+		summary.FallbackFailure += atomic.LoadInt64(&counter.FallbackFailure)
+		summary.SlowCall += atomic.LoadInt64(&counter.SlowCall)
+		histogram.Merge(&counter.Latency)
 	}
 
 	// 计算错误率。
-	summary.Total = summary.Success + summary.Failure
+	summary.RequestTotal = summary.Success + summary.Failure // Failure中已经包含了超时。
+	summary.Total = summary.RequestTotal
 	if summary.Total == 0 {
 		summary.ErrorPercentage = 0
 	} else {
 		summary.ErrorPercentage = float64(summary.Failure) / float64(summary.Total) * 100
+		summary.WeightedErrorPercentage = weightedFailure / weightedTotal * 100
 	}
 
+	if summary.RequestTotal > 0 {
+		summary.SlowCallPercentage = float64(summary.SlowCall) / float64(summary.RequestTotal) * 100
+	}
+	summary.MeanLatency = histogram.Mean()
+	summary.P99Latency = histogram.Percentile(99)
+	m.fillSummary(&summary, now)
+	return &summary
+}
+
+// fillSummary 用于填充摘要中与统计区间无关的配置与时间。
+func (m *Metric) fillSummary(summary *MetricSummary, now time.Time) {
 	summary.TimeWindowSecond = int64(m.timeWindow / time.Second)
 	summary.MetricIntervalSecond = int64(m.metricInterval / time.Second)
 
-	summary.LastExecuteTime = m.lastExecuteTime
-	summary.LastSuccessTime = m.lastSuccessTime
-	summary.LastTimeoutTime = m.lastTimeoutTime
-	summary.LastFailureTime = m.lastFailureTime
-
-	m.getSummaryCh <- &summary
+	summary.LastExecuteTime = loadTime(&m.lastExecuteTime)
+	summary.LastSuccessTime = loadTime(&m.lastSuccessTime)
+	summary.LastTimeoutTime = loadTime(&m.lastTimeoutTime)
+	summary.LastFailureTime = loadTime(&m.lastFailureTime)
+	summary.SampleTime = now
+
+	summary.CumulativeSuccess = atomic.LoadInt64(&m.totals.Success)
+	summary.CumulativeTimeout = atomic.LoadInt64(&m.totals.Timeout) // 先读超时再读失败，与Timeout的写入顺序相反。
+	summary.CumulativeFailure = atomic.LoadInt64(&m.totals.Failure)
+	summary.CumulativeFallbackSuccess = atomic.LoadInt64(&m.totals.FallbackSuccess)
+	summary.CumulativeFallbackFailure = atomic.LoadInt64(&m.totals.FallbackFailure)
 }
 
 // Summary 根据当前统计信息给出健康摘要。
 func (m *Metric) Summary() *MetricSummary {
-	m.makeSummaryCh <- struct{}{}
-	return <-m.getSummaryCh
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.makeSummary()
+}
+
+// Drain 返回上一次Drain以来的统计信息摘要，并同时重置这部分统计数据，用于按周期上报不重叠的统计数据。
+// 上报的统计数据与滑动窗口分开记录，Drain 不会重置滑动窗口，Reset 也不会重置上报的统计数据，熔断器的判断不受上报周期影响。
+// 计算摘要与重置在写锁内一次完成，期间到达的事件会计入下一个周期，不会丢失也不会重复统计。
+func (m *Metric) Drain() *MetricSummary {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	report := &m.report
+	summary := MetricSummary{
+		Success:         report.Success,
+		Timeout:         report.Timeout,
+		Failure:         report.Failure,
+		FallbackSuccess: report.FallbackSuccess,
+		FallbackFailure: report.FallbackFailure,
+		SlowCall:        report.SlowCall,
+	}
+	summary.RequestTotal = summary.Success + summary.Failure // Failure中已经包含了超时。
+	summary.Total = summary.RequestTotal
+	if summary.Total > 0 {
+		summary.ErrorPercentage = float64(summary.Failure) / float64(summary.Total) * 100
+		summary.WeightedErrorPercentage = summary.ErrorPercentage // 上报的统计数据不分区间，不加权。
+	}
+	if summary.RequestTotal > 0 {
+		summary.SlowCallPercentage = float64(summary.SlowCall) / float64(summary.RequestTotal) * 100
+	}
+	summary.MeanLatency = report.Latency.Mean()
+	summary.P99Latency = report.Latency.Percentile(99)
+	m.fillSummary(&summary, m.clock.Now())
+
+	report.Reset()
+	return &summary
+}
+
+// Snapshot 返回滑动窗口中各个统计块的数据副本，从旧到新，只包含仍在窗口中的统计块，没有记录过事件的区间不会出现。
+// 主要用于自定义熔断器与调试，返回的副本可以随意读写，不影响统计数据。
+func (m *Metric) Snapshot() []UnitCounter {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	current := m.slot(m.clock.Now())
+	snapshot := make([]UnitCounter, 0, len(m.counters))
+	for slot := current - int64(len(m.counters)) + 1; slot <= current; slot++ {
+		// 取模后的统计块可能属于更早的区间，需要判断区间序号。
+		counter := m.counters[m.index(slot)]
+		if atomic.LoadInt64(&counter.slot) != slot {
+			continue
+		}
+		snapshot = append(snapshot, counter.copy())
+	}
+	return snapshot
+}
+
+// copy 返回统计块的副本，统计块可以正在被并发记录。
+func (counter *UnitCounter) copy() UnitCounter {
+	c := UnitCounter{
+		Success:         atomic.LoadInt64(&counter.Success),
+		Timeout:         atomic.LoadInt64(&counter.Timeout),
+		Failure:         atomic.LoadInt64(&counter.Failure),
+		FallbackSuccess: atomic.LoadInt64(&counter.FallbackSuccess),
+		FallbackFailure: atomic.LoadInt64(&counter.FallbackFailure),
+		SlowCall:        atomic.LoadInt64(&counter.SlowCall),
+		slot:            atomic.LoadInt64(&counter.slot),
+	}
+	c.Latency.Merge(&counter.Latency)
+	return c
+}
+
+// LatencyBuckets 返回滑动窗口中按时间分布的耗时直方图，便于绘制热力图。
+// 第一维为统计区间，从旧到新，没有数据的区间各个桶都为0；第二维为各个耗时桶的次数，与 LatencyBucketBounds 对应。
+func (m *Metric) LatencyBuckets() [][]int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.makeLatencyBuckets()
+}
+
+// makeLatencyBuckets 根据当前统计块计算按时间分布的耗时直方图，调用方需要持有锁。
+func (m *Metric) makeLatencyBuckets() [][]int64 {
+	current := m.slot(m.clock.Now())
+	rows := make([][]int64, len(m.counters))
+	for i := range rows {
+		slot := current - int64(len(rows)-1-i)
+		rows[i] = make([]int64, latencyBucketCount)
+
+		// 取模后的统计块可能属于更早的区间，需要判断区间序号。
+		counter := m.counters[m.index(slot)]
+		if atomic.LoadInt64(&counter.slot) == slot {
+			for j := range rows[i] {
+				rows[i][j] = atomic.LoadInt64(&counter.Latency.Buckets[j])
+			}
+		}
+	}
+	return rows
 }
 
 // Success 记录一次成功事件。
 func (m *Metric) Success() {
-	m.successCh <- time.Now()
+	now := m.clock.Now()
+	m.lock.RLock()
+	atomic.AddInt64(&m.getCurrentCounter(now).Success, 1)
+	atomic.AddInt64(&m.report.Success, 1)
+	atomic.AddInt64(&m.totals.Success, 1)
+	m.lock.RUnlock()
+	storeTime(&m.lastExecuteTime, now)
+	storeTime(&m.lastSuccessTime, now)
 }
 
 // Timeout 记录一次超时事件。
 func (m *Metric) Timeout() {
-	m.timeoutCh <- time.Now()
+	now := m.clock.Now()
+	m.lock.RLock()
+	counter := m.getCurrentCounter(now)
+	// 超时也算失败的一种，这里也将失败加1。
+	// 先加失败再加超时，makeSummary先读超时再读失败，保证并发统计时失败数量不会小于超时数量。
+	atomic.AddInt64(&counter.Failure, 1)
+	atomic.AddInt64(&counter.Timeout, 1)
+	atomic.AddInt64(&m.report.Failure, 1)
+	atomic.AddInt64(&m.report.Timeout, 1)
+	atomic.AddInt64(&m.totals.Failure, 1)
+	atomic.AddInt64(&m.totals.Timeout, 1)
+	m.lock.RUnlock()
+	storeTime(&m.lastExecuteTime, now)
+	storeTime(&m.lastTimeoutTime, now)
 }
 
 // Failure 记录一次失败事件。
 func (m *Metric) Failure() {
-	m.failureCh <- time.Now()
+	now := m.clock.Now()
+	m.lock.RLock()
+	atomic.AddInt64(&m.getCurrentCounter(now).Failure, 1)
+	atomic.AddInt64(&m.report.Failure, 1)
+	atomic.AddInt64(&m.totals.Failure, 1)
+	m.lock.RUnlock()
+	storeTime(&m.lastExecuteTime, now)
+	storeTime(&m.lastFailureTime, now)
 }
 
 // FallbackSuccess 记录一次降级函数执行成功事件。
 func (m *Metric) FallbackSuccess() {
-	m.fallbackSuccessCh <- time.Now()
+	now := m.clock.Now()
+	m.lock.RLock()
+	atomic.AddInt64(&m.getCurrentCounter(now).FallbackSuccess, 1)
+	atomic.AddInt64(&m.report.FallbackSuccess, 1)
+	atomic.AddInt64(&m.totals.FallbackSuccess, 1)
+	m.lock.RUnlock()
+	storeTime(&m.lastExecuteTime, now)
 }
 
 // FallbackFailure 记录一次降级函数执行失败事件。
 func (m *Metric) FallbackFailure() {
-	m.fallbackFailureCh <- time.Now()
+	now := m.clock.Now()
+	m.lock.RLock()
+	atomic.AddInt64(&m.getCurrentCounter(now).FallbackFailure, 1)
+	atomic.AddInt64(&m.report.FallbackFailure, 1)
+	atomic.AddInt64(&m.totals.FallbackFailure, 1)
+	m.lock.RUnlock()
+	storeTime(&m.lastExecuteTime, now)
 }
 
-// Reset 用于重置所有统计数据。
-func (m *Metric) Reset() {
-	m.resetCh <- time.Now()
-}
-
-// run 用于开始统计数据处理。
-func (m *Metric) run() {
-	go func() {
-		for {
-			select {
-			case <-m.ctx.Done():
-				return // 结束。
-			case now := <-m.successCh:
-				m.doSuccess(now)
-			case now := <-m.timeoutCh:
-				m.doTimeout(now)
-			case now := <-m.failureCh:
-				m.doFailure(now)
-			case now := <-m.fallbackSuccessCh:
-				m.doFallbackSuccess(now)
-			case now := <-m.fallbackFailureCh:
-				m.doFallbackFailure(now)
-			case now := <-m.resetCh:
-				m.doReset(now)
-			case <-m.makeSummaryCh: // 获取Summary采用收到信号后计算并返回的方式。
-				m.makeSummary()
-			}
-		}
-	}()
+// Latency 记录一次功能函数执行耗时，与成功/失败等事件分开记录。
+func (m *Metric) Latency(d time.Duration) {
+	now := m.clock.Now()
+	m.lock.RLock()
+	m.getCurrentCounter(now).Latency.Record(d)
+	m.report.Latency.Record(d)
+	m.lock.RUnlock()
 }
-func (m *Metric) doSuccess(now time.Time) {
-	m.lastExecuteTime = now
-	m.lastSuccessTime = now
-	m.getCurrentCounter(now).Success++
+
+// SlowCall 记录一次慢调用事件，慢调用的标准由调用方决定。
+func (m *Metric) SlowCall() {
+	now := m.clock.Now()
+	m.lock.RLock()
+	atomic.AddInt64(&m.getCurrentCounter(now).SlowCall, 1)
+	atomic.AddInt64(&m.report.SlowCall, 1)
+	m.lock.RUnlock()
 }
 
-func (m *Metric) doTimeout(now time.Time) {
-	m.lastExecuteTime = now
-	m.lastTimeoutTime = now
-	m.getCurrentCounter(now).Timeout++
-	m.getCurrentCounter(now).Failure++ // 超时也算失败的一种，这里也将失败加1。
+// Reset 用于重置滑动窗口的所有统计数据，上报的统计数据由 Drain 重置。
+func (m *Metric) Reset() {
+	m.lock.Lock()
+	m.doReset(m.clock.Now())
+	m.lock.Unlock()
 }
 
-func (m *Metric) doFailure(now time.Time) {
-	m.lastExecuteTime = now
-	m.lastFailureTime = now
-	m.getCurrentCounter(now).Failure++
+// doReset 重置所有统计块，调用方需要持有写锁。
+func (m *Metric) doReset(now time.Time) {
+	storeTime(&m.lastResetTime, now)
+	for _, counter := range m.counters {
+		counter.Reset()
+	}
+}
+
+// getCurrentCounter 获取当前的统计块，调用方需要持有读锁。
+// 统计块还属于更早的区间时，会临时换成写锁完成重置，返回时依然持有读锁。
+func (m *Metric) getCurrentCounter(now time.Time) *UnitCounter {
+	// 按统计间隔计算事件所在的区间序号，对数组长度取模。
+	slot := m.slot(now)
+	counter := m.counters[m.index(slot)]
+
+	// 区间序号不小于事件所在区间就直接累加，更大时说明事件记录得太晚，计入新的区间即可。
+	for atomic.LoadInt64(&counter.slot) < slot {
+		m.lock.RUnlock()
+		m.lock.Lock()
+		// 只要区间序号不同，说明已经不在同一个统计区间，只是取模后结果相同而已，需要重置。
+		// 拿到写锁前可能已经被其它goroutine重置过，需要再判断一次。
+		if counter.slot < slot {
+			counter.Reset()
+			counter.slot = slot
+		}
+		m.lock.Unlock()
+		m.lock.RLock()
+	}
+
+	return counter
 }
 
-func (m *Metric) doFallbackSuccess(now time.Time) {
-	m.lastExecuteTime = now
-	m.getCurrentCounter(now).FallbackSuccess++
+// slot 返回指定时间所在的统计区间序号。
+func (m *Metric) slot(t time.Time) int64 {
+	return t.UnixNano() / int64(m.metricInterval)
 }
 
-func (m *Metric) doFallbackFailure(now time.Time) {
-	m.lastExecuteTime = now
-	m.getCurrentCounter(now).FallbackFailure++
+// index 返回区间序号在统计切片中的下标。
+func (m *Metric) index(slot int64) int {
+	return int(slot % int64(len(m.counters)))
 }
 
-func (m *Metric) doReset(now time.Time) {
-	m.lastResetTime = now
-	m.counters = make([]*UnitCounter, len(m.counters)) // 直接新建一个统计量。
+// inWindow 判断区间序号是否还在以current为最新区间的滑动窗口中。
+func (m *Metric) inWindow(current, slot int64) bool {
+	return slot <= current && current-slot < int64(len(m.counters))
 }
 
-// getCurrentCounter 获取当前的统计块。
-func (m *Metric) getCurrentCounter(now time.Time) *UnitCounter {
-	// 直接当前秒对数组长度取模。
-	index := int(time.Now().Unix()) % len(m.counters)
-	currentCounter := m.counters[index]
+// storeTime 原子保存时间。
+func storeTime(addr *int64, t time.Time) {
+	atomic.StoreInt64(addr, t.UnixNano())
+}
 
-	if currentCounter == nil {
-		currentCounter = &UnitCounter{}
-		m.counters[index] = currentCounter
-	} else {
-		// unix时间戳到秒，只要时间戳不同，说明已经不再同一秒，只是取模后结果相同而已，需要重置。
-		if now.Unix() != currentCounter.LastRecordTime.Unix() {
-			currentCounter.Reset()
-		}
+// loadTime 原子读取时间，没有记录时返回零值。
+func loadTime(addr *int64) time.Time {
+	nano := atomic.LoadInt64(addr)
+	if nano == 0 {
+		return time.Time{}
 	}
-
-	currentCounter.LastRecordTime = now // 每次获取都更新记录时间。
-	return currentCounter
+	return time.Unix(0, nano)
 }
 
 // MerticOption 是Mertic的可选项。
@@ -287,9 +468,31 @@ func WithMetricMetricInterval(metricInterval time.Duration) MerticOption {
 	}
 }
 
-// WithMetricContext 用于设置一个context，以便优雅退出内部消耗统计信息的gorotine。
-func WithMetricContext(ctx context.Context) MerticOption {
+// WithMetricBucketCount 设置滑动窗口中统计量的数量，每个统计量的间隔为窗口大小/n，可以小于1秒。
+// 如10s的窗口设置20个统计量，每个统计量为500ms；设置后将忽略 WithMetricMetricInterval。
+func WithMetricBucketCount(n int) MerticOption {
+	if n <= 0 {
+		panic("metric: bucketCount invalid") // 数量设置错误属于无法恢复的错误，直接panic把。
+	}
+	return func(m *Metric) {
+		m.bucketCount = n
+	}
+}
+
+// WithMetricBucketDuration 设置滑动窗口中每个统计量的间隔，可以小于1秒，窗口大小必须是它的整数倍。
+// 如1s的窗口设置100ms，共10个统计量，过期数据每100ms滑出窗口一次；设置后将忽略 WithMetricMetricInterval 与 WithMetricBucketCount。
+func WithMetricBucketDuration(d time.Duration) MerticOption {
+	if d <= 0 {
+		panic("metric: bucketDuration invalid") // 间隔大小设置错误属于无法恢复的错误，直接panic把。
+	}
+	return func(m *Metric) {
+		m.bucketDuration = d
+	}
+}
+
+// WithMetricClock 设置统计数据使用的时间源（默认系统时间），主要用于测试。
+func WithMetricClock(clock Clock) MerticOption {
 	return func(m *Metric) {
-		m.ctx = ctx
+		m.clock = clock
 	}
 }
@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func BenchmarkMetric_Success(b *testing.B) {
+	m := NewMetric(WithMetricTimeWindow(time.Second * 5))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Success()
+	}
+	b.StopTimer()
+}
+
+func BenchmarkParallelMetric_Success(b *testing.B) {
+	m := NewMetric(WithMetricTimeWindow(time.Second * 5))
+	b.ReportAllocs()
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			m.Success()
+		}
+	})
+}
+
+func BenchmarkParallelMetric_SuccessAndSummary(b *testing.B) {
+	m := NewMetric(WithMetricTimeWindow(time.Second * 5))
+	b.ReportAllocs()
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			m.Summary()
+			m.Success()
+		}
+	})
+}
@@ -115,3 +115,458 @@ func validateMetricCollect(t *testing.T, name string, m *Metric,
 		t.Errorf("%s: summary.ErrorPercentage is wrong, want %f, but %f", name, errorPercentage, summary.ErrorPercentage)
 	}
 }
+
+// TestMetric_drain 测试Drain在并发写入时不丢失也不重复统计数据。
+func TestMetric_drain(t *testing.T) {
+	t.Parallel()
+	m := NewMetric(WithMetricTimeWindow(time.Second * 5))
+
+	const writerCount = 10
+	const eventCount = 1000
+
+	var wg sync.WaitGroup
+	for i := 0; i < writerCount; i++ {
+		wg.Add(1)
+		go func() {
+			for j := 0; j < eventCount; j++ {
+				m.Success()
+			}
+			wg.Done()
+		}()
+	}
+
+	// 写入的同时不断Drain。
+	var drained int64
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			drained += m.Drain().Success
+		}
+	}
+
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+	drained += m.Drain().Success
+
+	if drained != writerCount*eventCount {
+		t.Errorf("Metric.Drain() total got = %d, want %d", drained, writerCount*eventCount)
+	}
+	if summary := m.Summary(); summary.Success != writerCount*eventCount { // Drain 不重置滑动窗口。
+		t.Errorf("Metric.Summary() after Drain got = %d, want %d", summary.Success, writerCount*eventCount)
+	}
+}
+
+// TestMetric_visibility 测试记录的事件立即可见，不需要等待。
+func TestMetric_visibility(t *testing.T) {
+	t.Parallel()
+	m := NewMetric(WithMetricTimeWindow(time.Second * 5))
+
+	m.Success()
+	m.Timeout()
+	m.Failure()
+	validateMetricCollect(t, "immediate", m, 1, 1, 1, 0, 0, 3, float64(2)/3*100)
+
+	m.Reset()
+	validateMetricCollect(t, "reset", m, 0, 0, 0, 0, 0, 0, 0)
+
+	doMetricCollect(m, 400, 90, 10, 2, 4)
+	validateMetricCollect(t, "concurrent", m, 400, 90, 10, 2, 4, 500, float64(100)/500*100)
+	m.Drain()
+	validateMetricCollect(t, "drain", m, 400, 90, 10, 2, 4, 500, float64(100)/500*100) // Drain 不重置滑动窗口。
+}
+
+// TestMetric_drainReport 测试Drain返回上一次Drain以来的统计数据，与滑动窗口的过期与重置相互独立。
+func TestMetric_drainReport(t *testing.T) {
+	t.Parallel()
+	clock := &testClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := NewMetric(WithMetricTimeWindow(time.Second*2), WithMetricClock(clock))
+
+	m.Success()
+	m.Failure()
+	m.Latency(10 * time.Millisecond)
+	clock.Advance(time.Second * 5) // 已经滑出窗口，依然需要上报。
+	m.Timeout()
+	m.Reset() // 重置滑动窗口，不影响上报。
+	m.FallbackSuccess()
+
+	summary := m.Drain()
+	if summary.Success != 1 || summary.Failure != 2 || summary.Timeout != 1 || summary.FallbackSuccess != 1 || summary.RequestTotal != 3 {
+		t.Errorf("Metric.Drain() got = %+v, want Success 1, Failure 2, Timeout 1, FallbackSuccess 1, RequestTotal 3", summary)
+	}
+	if summary.ErrorPercentage != float64(2)/3*100 || summary.MeanLatency != 10*time.Millisecond {
+		t.Errorf("Metric.Drain() ErrorPercentage/MeanLatency got = %v/%v, want %v/%v", summary.ErrorPercentage, summary.MeanLatency, float64(2)/3*100, 10*time.Millisecond)
+	}
+	if summary := m.Summary(); summary.FallbackSuccess != 1 {
+		t.Errorf("Metric.Summary() after Drain FallbackSuccess got = %d, want %d", summary.FallbackSuccess, 1)
+	}
+	if summary := m.Drain(); summary.RequestTotal != 0 || summary.FallbackSuccess != 0 {
+		t.Errorf("Metric.Drain() again got = %+v, want empty", summary)
+	}
+}
+
+// TestMetric_latency 测试耗时直方图在滑动窗口中的聚合与过期。
+func TestMetric_latency(t *testing.T) {
+	t.Parallel()
+	clock := &testClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := NewMetric(WithMetricTimeWindow(time.Second*2), WithMetricClock(clock))
+
+	validateLatency := func(name string, mean, p99 time.Duration) {
+		summary := m.Summary()
+		if summary.MeanLatency != mean {
+			t.Errorf("%s: Metric.Summary() MeanLatency got = %v, want %v", name, summary.MeanLatency, mean)
+		}
+		if summary.P99Latency != p99 {
+			t.Errorf("%s: Metric.Summary() P99Latency got = %v, want %v", name, summary.P99Latency, p99)
+		}
+	}
+	validateLatency("empty", 0, 0)
+
+	for i := 0; i < 98; i++ {
+		m.Latency(time.Millisecond * 3)
+	}
+	validateLatency("first unit", time.Millisecond*3, time.Millisecond*3) // 所在桶的上限超过了最大耗时，取最大耗时。
+
+	clock.Advance(time.Second) // 进入下一个统计单元。
+	m.Latency(time.Millisecond * 200)
+	m.Latency(time.Millisecond * 200)
+	// 两个统计单元合并计算，99分位落在250ms的桶中，但不会超过最大耗时。
+	validateLatency("across units", (98*time.Millisecond*3+2*time.Millisecond*200)/100, time.Millisecond*200)
+
+	clock.Advance(time.Second) // 第一个统计单元滑出窗口。
+	validateLatency("expired", time.Millisecond*200, time.Millisecond*200)
+
+	m.Reset()
+	validateLatency("reset", 0, 0)
+}
+
+// testClock 是用于测试的 Clock 实现，只有手动设置时时间才会变化。
+type testClock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// TestMetric_LatencyBuckets 测试按时间分布的耗时直方图的形状。
+func TestMetric_LatencyBuckets(t *testing.T) {
+	t.Parallel()
+	clock := &testClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := NewMetric(WithMetricTimeWindow(time.Second*3), WithMetricClock(clock))
+
+	// 第1秒：2个3ms（第2个桶）；第2秒没有数据；第3秒：1个20ms（第4个桶）、1个1分钟（溢出桶）。
+	m.Latency(time.Millisecond * 3)
+	m.Latency(time.Millisecond * 3)
+	clock.Advance(time.Second * 2)
+	m.Latency(time.Millisecond * 20)
+	m.Latency(time.Minute)
+
+	buckets := m.LatencyBuckets()
+	if len(buckets) != 3 {
+		t.Fatalf("Metric.LatencyBuckets() len got = %d, want %d", len(buckets), 3)
+	}
+	for i, row := range buckets {
+		if len(row) != len(LatencyBucketBounds())+1 {
+			t.Fatalf("Metric.LatencyBuckets()[%d] len got = %d, want %d", i, len(row), len(LatencyBucketBounds())+1)
+		}
+	}
+	want := map[[2]int]int64{{0, 1}: 2, {2, 3}: 1, {2, len(LatencyBucketBounds())}: 1}
+	for i, row := range buckets {
+		for j, count := range row {
+			if count != want[[2]int{i, j}] {
+				t.Errorf("Metric.LatencyBuckets()[%d][%d] got = %d, want %d", i, j, count, want[[2]int{i, j}])
+			}
+		}
+	}
+
+	// 第1秒的数据滑出窗口，整体前移。
+	clock.Advance(time.Second)
+	buckets = m.LatencyBuckets()
+	if buckets[1][3] != 1 || buckets[0][1] != 0 {
+		t.Errorf("Metric.LatencyBuckets() after advance got = %v", buckets)
+	}
+}
+
+// TestMetric_bucketCount 测试统计量数量与窗口大小解耦后，过期的精度为每个统计量的间隔。
+func TestMetric_bucketCount(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		bucketCount int
+		want        int64 // 10.2s时窗口内的成功数量。
+	}{
+		{"10 buckets", 10, 0}, // 1s一个统计量，两次记录在同一个统计量中，一起滑出窗口。
+		{"20 buckets", 20, 1}, // 500ms一个统计量，只有第一次记录已经过期。
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &testClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+			m := NewMetric(
+				WithMetricTimeWindow(time.Second*10),
+				WithMetricBucketCount(tt.bucketCount),
+				WithMetricClock(clock))
+
+			m.Success()
+			clock.Advance(time.Millisecond * 500)
+			m.Success()
+
+			clock.Advance(time.Millisecond * 9700) // 10.2s。
+			if summary := m.Summary(); summary.Success != tt.want {
+				t.Errorf("Metric.Summary() Success got = %d, want %d", summary.Success, tt.want)
+			}
+
+			clock.Advance(time.Millisecond * 400) // 10.6s，全部过期。
+			if summary := m.Summary(); summary.Success != 0 {
+				t.Errorf("Metric.Summary() Success got = %d, want %d", summary.Success, 0)
+			}
+		})
+	}
+}
+
+// TestMetric_boundary 测试在区间边界前后快速记录时，摘要只包含窗口内的统计块，不会重复或遗漏。
+func TestMetric_boundary(t *testing.T) {
+	t.Parallel()
+	clock := &testClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := NewMetric(WithMetricTimeWindow(time.Second*3), WithMetricClock(clock))
+
+	// 在每个1s边界的前后1ms各记录一次，共6次，区间0-3分别为1、2、2、1次。
+	clock.Advance(time.Millisecond * 999)
+	for i := 0; i < 3; i++ {
+		m.Success()
+		clock.Advance(time.Millisecond * 2)
+		m.Success()
+		clock.Advance(time.Millisecond * 998)
+	}
+
+	tests := []struct {
+		name    string
+		advance time.Duration // 相对上一步推进的时间。
+		want    int64
+	}{
+		{"before boundary", 0, 5},                    // 3.999s：区间1-3。
+		{"on boundary", time.Millisecond, 3},         // 4.000s：区间2-4，区间1的两次滑出窗口。
+		{"same interval", time.Millisecond * 999, 3}, // 4.999s：依然是区间2-4。
+		{"next boundary", time.Millisecond, 1},       // 5.000s：区间3-5，只剩最后一次。
+		{"all expired", time.Second, 0},              // 6.000s：区间4-6。
+	}
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		if summary := m.Summary(); summary.Success != tt.want {
+			t.Errorf("%s: Metric.Summary() Success got = %d, want %d", tt.name, summary.Success, tt.want)
+		}
+	}
+}
+
+// TestMetric_weightedErrorPercentage 测试按时间线性加权的错误百分比。
+func TestMetric_weightedErrorPercentage(t *testing.T) {
+	t.Parallel()
+	clock := &testClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := NewMetric(WithMetricTimeWindow(time.Second*3), WithMetricClock(clock))
+
+	// 第1秒10次成功，第3秒5次成功、5次失败。
+	for i := 0; i < 10; i++ {
+		m.Success()
+	}
+	clock.Advance(time.Second * 2)
+	for i := 0; i < 5; i++ {
+		m.Success()
+		m.Failure()
+	}
+
+	summary := m.Summary()
+	if summary.ErrorPercentage != 25 { // 5/20。
+		t.Errorf("Metric.Summary() ErrorPercentage got = %v, want %v", summary.ErrorPercentage, 25)
+	}
+	if summary.WeightedErrorPercentage != 37.5 { // 3*5/(1*10+3*10)。
+		t.Errorf("Metric.Summary() WeightedErrorPercentage got = %v, want %v", summary.WeightedErrorPercentage, 37.5)
+	}
+}
+
+// TestMetric_timeoutCounting 测试超时计入失败且只计算一次。
+func TestMetric_timeoutCounting(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		success         int
+		failure         int // 非超时的失败数量。
+		timeout         int
+		fallbackSuccess int
+		fallbackFailure int
+		errorPercentage float64
+	}{
+		{"empty", 0, 0, 0, 0, 0, 0},
+		{"success only", 4, 0, 0, 0, 0, 0},
+		{"failure only", 0, 4, 0, 4, 0, 100},
+		{"timeout only", 0, 0, 4, 0, 4, 100},
+		{"failure and timeout", 0, 2, 2, 2, 2, 100},
+		{"mixed", 4, 2, 2, 3, 1, 50},
+		{"fallback excluded", 6, 1, 1, 2, 0, 25},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			clock := &testClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+			m := NewMetric(WithMetricTimeWindow(time.Second*5), WithMetricClock(clock))
+			for i := 0; i < tt.success; i++ {
+				m.Success()
+			}
+			for i := 0; i < tt.failure; i++ {
+				m.Failure()
+			}
+			for i := 0; i < tt.timeout; i++ {
+				m.Timeout()
+			}
+			for i := 0; i < tt.fallbackSuccess; i++ {
+				m.FallbackSuccess()
+			}
+			for i := 0; i < tt.fallbackFailure; i++ {
+				m.FallbackFailure()
+			}
+
+			summary := m.Summary()
+			if want := int64(tt.failure + tt.timeout); summary.Failure != want {
+				t.Errorf("Metric.Summary() Failure got = %d, want %d", summary.Failure, want)
+			}
+			if want := int64(tt.timeout); summary.Timeout != want {
+				t.Errorf("Metric.Summary() Timeout got = %d, want %d", summary.Timeout, want)
+			}
+			if want := int64(tt.success + tt.failure + tt.timeout); summary.RequestTotal != want || summary.Total != want {
+				t.Errorf("Metric.Summary() RequestTotal, Total got = %d, %d, want %d", summary.RequestTotal, summary.Total, want)
+			}
+			if summary.ErrorPercentage != tt.errorPercentage {
+				t.Errorf("Metric.Summary() ErrorPercentage got = %v, want %v", summary.ErrorPercentage, tt.errorPercentage)
+			}
+		})
+	}
+}
+
+// TestMetric_bucketDuration 测试小于1秒的统计块间隔，1s窗口100ms一个统计块。
+func TestMetric_bucketDuration(t *testing.T) {
+	t.Parallel()
+	clock := &testClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := NewMetric(
+		WithMetricTimeWindow(time.Second),
+		WithMetricBucketDuration(time.Millisecond*100),
+		WithMetricClock(clock))
+
+	if n := len(m.LatencyBuckets()); n != 10 {
+		t.Fatalf("Metric.LatencyBuckets() len got = %d, want %d", n, 10)
+	}
+
+	// 0-900ms每100ms记录一次失败，共10次，分布在10个统计块中。
+	for i := 0; i < 10; i++ {
+		if i > 0 {
+			clock.Advance(time.Millisecond * 100)
+		}
+		m.Failure()
+	}
+
+	tests := []struct {
+		name    string
+		advance time.Duration // 相对上一步推进的时间。
+		want    int64
+	}{
+		{"all in window", time.Millisecond * 50, 10}, // 0.95s：统计块0-9。
+		{"first expired", time.Millisecond * 100, 9}, // 1.05s：统计块1-10。
+		{"half expired", time.Millisecond * 400, 5},  // 1.45s：统计块5-14。
+		{"last one left", time.Millisecond * 400, 1}, // 1.85s：统计块9-18。
+		{"all expired", time.Millisecond * 100, 0},   // 1.95s：统计块10-19。
+	}
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		if summary := m.Summary(); summary.Failure != tt.want {
+			t.Errorf("%s: Metric.Summary() Failure got = %d, want %d", tt.name, summary.Failure, tt.want)
+		}
+	}
+
+	// 窗口大小不是间隔的整数倍时panic。
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("NewMetric() want panic")
+			}
+		}()
+		NewMetric(WithMetricTimeWindow(time.Second), WithMetricBucketDuration(time.Millisecond*300))
+	}()
+}
+
+// TestMetric_Snapshot 测试各个统计块的数据副本之和与摘要一致。
+func TestMetric_Snapshot(t *testing.T) {
+	t.Parallel()
+	clock := &testClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := NewMetric(WithMetricTimeWindow(time.Second*5), WithMetricClock(clock))
+
+	// 第1秒的数据会在最后滑出窗口，第3秒没有数据。
+	records := []struct {
+		advance time.Duration
+		success int
+		failure int
+		timeout int
+	}{
+		{0, 7, 7, 7},
+		{time.Second, 1, 2, 3},
+		{time.Second * 2, 4, 0, 1},
+		{time.Second, 2, 5, 0},
+	}
+	for _, r := range records {
+		clock.Advance(r.advance)
+		for i := 0; i < r.success; i++ {
+			m.Success()
+			m.Latency(time.Millisecond)
+		}
+		for i := 0; i < r.failure; i++ {
+			m.Failure()
+		}
+		for i := 0; i < r.timeout; i++ {
+			m.Timeout()
+		}
+	}
+	clock.Advance(time.Second) // 第6秒，第1秒的数据过期。
+
+	snapshot := m.Snapshot()
+	if len(snapshot) != 3 {
+		t.Fatalf("Metric.Snapshot() len got = %d, want %d", len(snapshot), 3)
+	}
+	wantSuccess := []int64{1, 4, 2} // 从旧到新。
+	var sum UnitCounter
+	for i, counter := range snapshot {
+		if counter.Success != wantSuccess[i] {
+			t.Errorf("Metric.Snapshot()[%d] Success got = %d, want %d", i, counter.Success, wantSuccess[i])
+		}
+		sum.Success += counter.Success
+		sum.Failure += counter.Failure
+		sum.Timeout += counter.Timeout
+		sum.Latency.Merge(&counter.Latency)
+	}
+
+	summary := m.Summary()
+	if sum.Success != summary.Success || sum.Failure != summary.Failure || sum.Timeout != summary.Timeout {
+		t.Errorf("Metric.Snapshot() sum got = %d/%d/%d, want %d/%d/%d",
+			sum.Success, sum.Failure, sum.Timeout, summary.Success, summary.Failure, summary.Timeout)
+	}
+	if sum.Latency.Mean() != summary.MeanLatency {
+		t.Errorf("Metric.Snapshot() mean latency got = %v, want %v", sum.Latency.Mean(), summary.MeanLatency)
+	}
+
+	// 修改副本不影响统计数据。
+	snapshot[0].Success = 100
+	if summary := m.Summary(); summary.Success != 7 {
+		t.Errorf("Metric.Summary() Success got = %d, want %d", summary.Success, 7)
+	}
+}
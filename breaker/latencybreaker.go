@@ -0,0 +1,296 @@
+package breaker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bunnier/circuit/breaker/internal"
+)
+
+var _ Breaker = (*latencyBreaker)(nil)
+var _ Configurable = (*latencyBreaker)(nil)
+
+// latencyBreaker 是 Breaker 的一种实现。
+type latencyBreaker struct {
+	ctx context.Context // 用于释放资源的context。
+
+	name   string           // 名称。
+	metric *internal.Metric // 执行情况统计数据。
+
+	internalStatus int32 // 熔断器的内部状态，内部维护3个状态。
+	forcedStatus   int32 // 手动强制状态，优先于内部状态。
+	openedAt       int64 // 最后一次开启的时间（UnixNano），休眠时间从此刻起算。
+	halfOpenedAt   int64 // 最后一次进入半开状态的时间（UnixNano），用于区分尝试请求与开启前放行、此时才返回的请求。
+	probeLatency   int64 // 尝试请求的执行耗时（纳秒），小于0表示还没有记录，半开状态时用于决定关闭还是重新开启。
+
+	halfOpenLock sync.Mutex // 用于保证进入半开状态时，状态与半开时间、尝试请求耗时一起生效。
+
+	latencyThreshold    time.Duration // 开启熔断的平均耗时阈值。
+	minRequestThreshold int64         // 熔断器生效必须满足的最小流量。
+	sleepWindow         time.Duration // 熔断后重置熔断器的时间窗口。
+	timeWindow          time.Duration // 滑动窗口的大小（单位秒1-60）。
+	clock               Clock         // 休眠与统计数据使用的时间源（可选）。
+}
+
+// NewLatencyBreaker 用于新建一个 LatencyBreaker 熔断器。
+// LatencyBreaker 在滑动窗口内的平均耗时超过阈值后开启，即使所有请求都执行成功，用于发现下游变慢但没有报错的情况。
+// 恢复算法与 CutBreaker 相同：开启后休眠指定时间进入半开状态，只允许一个请求进入尝试，
+// 该请求成功且耗时不超过阈值就关闭并重置统计，超过阈值、失败或超时则重新开启。
+// 注意：该熔断器依赖 Latency 记录的耗时，需要通过 Command 使用，或自行在记录执行结果前调用 Latency。
+func NewLatencyBreaker(name string, options ...LatencyBreakerOption) *latencyBreaker {
+	b := &latencyBreaker{
+		ctx:                 context.Background(),
+		name:                name,
+		internalStatus:      Closed, // 默认关闭。
+		latencyThreshold:    time.Second,
+		minRequestThreshold: 20, // 默认20个请求起算。
+		sleepWindow:         time.Second * 5,
+		timeWindow:          time.Second * 5,
+	}
+
+	for _, option := range options {
+		option(b)
+	}
+
+	// 初始化选项后，根据选项初始化Metric。
+	metricOptions := []internal.MerticOption{
+		internal.WithMetricTimeWindow(b.timeWindow),
+	}
+	if b.clock != nil {
+		metricOptions = append(metricOptions, internal.WithMetricClock(b.clock))
+	}
+	b.metric = internal.NewMetric(metricOptions...)
+
+	return b
+}
+
+// Allow 用于判断断路器是否允许通过请求。
+// 第一返回值：true能通过/false不能；第二返回值：当前Breaker状态的文字描述。
+func (b *latencyBreaker) Allow() (bool, string) {
+	summary := b.metric.Summary() // 当前健康统计。
+	return b.allow(summary)
+}
+
+// allow 用于判断断路器是否允许通过请求。
+// 第一返回值：true能通过/false不能；第二返回值：当前Breaker状态的文字描述。
+func (b *latencyBreaker) allow(summary *internal.MetricSummary) (bool, string) {
+	if pass, statusMsg, forced := forcedAllow(&b.forcedStatus); forced {
+		return pass, statusMsg // 手动强制状态优先于自动判断。
+	}
+
+	switch atomic.LoadInt32(&b.internalStatus) {
+	case Closed:
+		// 没有满足最小流量要求 或 平均耗时没有超过阈值。
+		if summary.Total < b.minRequestThreshold || summary.MeanLatency <= b.latencyThreshold {
+			return true, "closed"
+		}
+		b.transit(Closed, Openning)
+		return false, "open" // 无论上面结果如何，都开启。
+
+	case HalfOpening:
+		return false, "half-open" // 半开状态，说明已经有一个请求正在尝试，拒绝所有其它请求。
+
+	case Openning:
+		// 判断是否已过休眠时间，从开启时刻起算，不受开启期间降级等其它统计事件的影响。
+		if b.now().Sub(time.Unix(0, atomic.LoadInt64(&b.openedAt))) < b.sleepWindow {
+			return false, "open"
+		}
+		// 过了休眠时间，设置为半开状态，并放一个请求试试，换不到的还是开启。
+		return b.tryHalfOpen(), "half-open"
+
+	default:
+		panic("breaker: impossible status")
+	}
+}
+
+// transit 用于通过CAS切换熔断器内部状态，切换到开启状态时记录开启时间。
+// 开启时间在切换前记录，并发的 allow 看到开启状态时，开启时间一定属于本次开启。
+func (b *latencyBreaker) transit(from, to int32) bool {
+	if to == Openning {
+		if atomic.LoadInt32(&b.internalStatus) != from { // 不会切换成功，不能改写其它轮次的开启时间。
+			return false
+		}
+		atomic.StoreInt64(&b.openedAt, b.now().UnixNano()) // 并发切换时落选的一方也会记录，休眠时间只会稍晚结束。
+	}
+	return atomic.CompareAndSwapInt32(&b.internalStatus, from, to)
+}
+
+// tryHalfOpen 用于从开启状态切换为半开状态，切换成功的请求就是唯一的尝试请求。
+// 半开时间与尝试请求耗时在状态对外生效前初始化，Latency 看到半开状态时，它们一定属于本轮。
+func (b *latencyBreaker) tryHalfOpen() bool {
+	b.halfOpenLock.Lock()
+	defer b.halfOpenLock.Unlock()
+	if atomic.LoadInt32(&b.internalStatus) != Openning { // 已经被其它请求切换了。
+		return false
+	}
+	atomic.StoreInt64(&b.halfOpenedAt, b.now().UnixNano())
+	atomic.StoreInt64(&b.probeLatency, -1)
+	return atomic.CompareAndSwapInt32(&b.internalStatus, Openning, HalfOpening)
+}
+
+// now 返回当前时间，没有设置时间源时使用系统时间。
+func (b *latencyBreaker) now() time.Time {
+	if b.clock != nil {
+		return b.clock.Now()
+	}
+	return time.Now()
+}
+
+// Success 用于记录成功事件，半开状态时根据尝试请求自己的耗时决定关闭还是重新开启。
+// Command 会先记录耗时再记录执行结果，因此在这里而不是 Latency 中做决定，保证失败的尝试请求不会关闭熔断器；
+// 尝试请求还没有记录耗时的（如开启前放行、此时才返回的请求先返回了），不做决定。
+func (b *latencyBreaker) Success() {
+	if atomic.LoadInt32(&b.internalStatus) == HalfOpening {
+		if probe := atomic.LoadInt64(&b.probeLatency); time.Duration(probe) > b.latencyThreshold {
+			b.transit(HalfOpening, Openning)
+		} else if probe >= 0 {
+			b.metric.Reset() // 注意：这里需要先Reset metric再改状态，以免之前的慢请求马上又开启熔断器。
+			b.transit(HalfOpening, Closed)
+		}
+	}
+	b.metric.Success()
+}
+
+// Failure 用于记录失败事件。
+func (b *latencyBreaker) Failure() {
+	b.transit(HalfOpening, Openning)
+	b.metric.Failure()
+}
+
+// Timeout 用于记录超时事件。
+func (b *latencyBreaker) Timeout() {
+	b.transit(HalfOpening, Openning)
+	b.metric.Timeout()
+}
+
+// Latency 记录一次功能函数执行耗时，半开状态的决定留给之后记录的执行结果。
+// 半开状态下，只有进入半开状态之后才开始执行的请求（即尝试请求）的耗时用于决定，开启前放行、此时才返回的请求不会覆盖它。
+func (b *latencyBreaker) Latency(d time.Duration) {
+	if atomic.LoadInt32(&b.internalStatus) == HalfOpening &&
+		!b.now().Add(-d).Before(time.Unix(0, atomic.LoadInt64(&b.halfOpenedAt))) {
+		atomic.StoreInt64(&b.probeLatency, int64(d))
+	}
+	b.metric.Latency(d)
+}
+
+// FallbackSuccess 记录一次降级函数执行成功事件。
+func (b *latencyBreaker) FallbackSuccess() {
+	b.metric.FallbackSuccess()
+}
+
+// FallbackFailure 记录一次降级函数执行失败事件。
+func (b *latencyBreaker) FallbackFailure() {
+	b.metric.FallbackFailure()
+}
+
+// Summary 返回当前健康状态。
+func (b *latencyBreaker) Summary() *BreakerSummary {
+	return newBreakerSummary(b.name, b.status(), b.metric.Summary(), b.minRequestThreshold)
+}
+
+// Drain 返回当前健康状态，并同时重置上报用的统计数据。
+func (b *latencyBreaker) Drain() *BreakerSummary {
+	return newBreakerSummary(b.name, b.status(), b.metric.Drain(), b.minRequestThreshold)
+}
+
+// status 返回当前状态的文字描述，只读取状态，不会像 allow 一样切换状态，状态切换只在 Allow 与记录结果时发生。
+func (b *latencyBreaker) status() string {
+	if _, statusMsg, forced := forcedAllow(&b.forcedStatus); forced {
+		return statusMsg
+	}
+	return State(atomic.LoadInt32(&b.internalStatus)).String()
+}
+
+// LatencyBuckets 返回滑动窗口中按时间分布的耗时直方图。
+func (b *latencyBreaker) LatencyBuckets() [][]int64 {
+	return b.metric.LatencyBuckets()
+}
+
+// State 返回熔断器当前状态。
+func (b *latencyBreaker) State() State {
+	if isForced(&b.forcedStatus) {
+		return StateForced
+	}
+	return State(atomic.LoadInt32(&b.internalStatus))
+}
+
+// Reset 用于立即关闭熔断器，并清空统计数据。
+func (b *latencyBreaker) Reset() {
+	b.metric.Reset() // 先Reset metric再改状态，以免之前的慢请求马上又开启熔断器。
+	atomic.StoreInt32(&b.internalStatus, Closed)
+}
+
+// Config 返回熔断器的有效配置。
+func (b *latencyBreaker) Config() BreakerConfig {
+	return BreakerConfig{
+		Type: "latency",
+		Options: map[string]interface{}{
+			"timeWindow":          b.timeWindow,
+			"latencyThreshold":    b.latencyThreshold,
+			"minRequestThreshold": b.minRequestThreshold,
+			"sleepWindow":         b.sleepWindow,
+		},
+	}
+}
+
+// ForceOpen 用于手动强制开启熔断器，强制期间拒绝所有请求。
+func (b *latencyBreaker) ForceOpen() {
+	atomic.StoreInt32(&b.forcedStatus, forcedOpen)
+}
+
+// ForceClose 用于手动强制关闭熔断器，强制期间放行所有请求。
+func (b *latencyBreaker) ForceClose() {
+	atomic.StoreInt32(&b.forcedStatus, forcedClosed)
+}
+
+// ClearForced 用于清除手动强制状态，恢复熔断器的自动判断。
+func (b *latencyBreaker) ClearForced() {
+	atomic.StoreInt32(&b.forcedStatus, forcedNone)
+}
+
+// LatencyBreakerOption 是 LatencyBreaker 的可选项。
+type LatencyBreakerOption func(b *latencyBreaker)
+
+// WithLatencyThreshold 设置开启熔断的平均耗时阈值。
+func WithLatencyThreshold(d time.Duration) LatencyBreakerOption {
+	return func(b *latencyBreaker) {
+		b.latencyThreshold = d
+	}
+}
+
+// WithLatencyBreakerMinRequestThreshold 设置熔断器生效必须满足的最小流量。
+func WithLatencyBreakerMinRequestThreshold(minRequestThreshold int64) LatencyBreakerOption {
+	return func(b *latencyBreaker) {
+		b.minRequestThreshold = minRequestThreshold
+	}
+}
+
+// WithLatencyBreakerSleepWindow 设置熔断后重置熔断器的时间窗口。
+func WithLatencyBreakerSleepWindow(sleepWindow time.Duration) LatencyBreakerOption {
+	return func(b *latencyBreaker) {
+		b.sleepWindow = sleepWindow
+	}
+}
+
+// WithLatencyBreakerTimeWindow 设置滑动窗口的大小（要求1-60s）。
+func WithLatencyBreakerTimeWindow(timeWindow time.Duration) LatencyBreakerOption {
+	return func(b *latencyBreaker) {
+		b.timeWindow = timeWindow
+	}
+}
+
+// WithLatencyBreakerClock 设置休眠与统计数据使用的时间源（默认系统时间），用于在测试中驱动时间。
+func WithLatencyBreakerClock(clock Clock) LatencyBreakerOption {
+	return func(b *latencyBreaker) {
+		b.clock = clock
+	}
+}
+
+// WithLatencyBreakerContext 设置用于释放资源的context。
+func WithLatencyBreakerContext(ctx context.Context) LatencyBreakerOption {
+	return func(b *latencyBreaker) {
+		b.ctx = ctx
+	}
+}
@@ -0,0 +1,210 @@
+package breaker
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestLatencyBreaker_workflow 测试先快后慢的请求全部成功时，熔断器依然能根据耗时开启，并通过半开尝试恢复。
+func TestLatencyBreaker_workflow(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := NewLatencyBreaker("test",
+		WithLatencyThreshold(100*time.Millisecond),
+		WithLatencyBreakerMinRequestThreshold(10),
+		WithLatencyBreakerSleepWindow(500*time.Millisecond),
+		WithLatencyBreakerClock(clock))
+
+	// 执行耗时d后记录结果，与 Command 一样先记录耗时，再记录执行结果。
+	record := func(d time.Duration) {
+		clock.Advance(d)
+		breaker.Latency(d)
+		breaker.Success()
+	}
+
+	// 快速请求，平均耗时远低于阈值。
+	for i := 0; i < 10; i++ {
+		record(10 * time.Millisecond)
+	}
+	if pass, statusMsg := breaker.Allow(); !pass || statusMsg != "closed" {
+		t.Errorf("LatencyBreaker.Allow() got = %v, %v, want %v, %v", pass, statusMsg, true, "closed")
+	}
+
+	// 慢请求，虽然全部成功，但平均耗时超过阈值（(10*10+10*300)/20 = 155ms）。
+	for i := 0; i < 10; i++ {
+		record(300 * time.Millisecond)
+	}
+	if pass, statusMsg := breaker.Allow(); pass || statusMsg != "open" {
+		t.Errorf("LatencyBreaker.Allow() got = %v, %v, want %v, %v", pass, statusMsg, false, "open")
+	}
+	if state := breaker.State(); state != StateOpen {
+		t.Errorf("LatencyBreaker.State() got = %v, want %v", state, StateOpen)
+	}
+
+	// 睡眠期结束，放一个依然很慢的请求，重新开启。
+	clock.Advance(600 * time.Millisecond)
+	if pass, statusMsg := breaker.Allow(); !pass || statusMsg != "half-open" {
+		t.Errorf("LatencyBreaker.Allow() got = %v, %v, want %v, %v", pass, statusMsg, true, "half-open")
+	}
+	record(300 * time.Millisecond) // 休眠时间从此刻起算。
+	if pass, statusMsg := breaker.Allow(); pass || statusMsg != "open" {
+		t.Errorf("LatencyBreaker.Allow() got = %v, %v, want %v, %v", pass, statusMsg, false, "open")
+	}
+
+	// 睡眠期结束，放一个快的请求，关闭。
+	clock.Advance(600 * time.Millisecond)
+	if pass, statusMsg := breaker.Allow(); !pass || statusMsg != "half-open" {
+		t.Errorf("LatencyBreaker.Allow() got = %v, %v, want %v, %v", pass, statusMsg, true, "half-open")
+	}
+	record(10 * time.Millisecond)
+	if pass, statusMsg := breaker.Allow(); !pass || statusMsg != "closed" {
+		t.Errorf("LatencyBreaker.Allow() got = %v, %v, want %v, %v", pass, statusMsg, true, "closed")
+	}
+}
+
+// TestLatencyBreaker_summaryAndSleepWindow 测试读取统计数据不会切换状态，且休眠时间从开启时刻起算，不受开启期间降级事件的影响。
+func TestLatencyBreaker_summaryAndSleepWindow(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := NewLatencyBreaker("test",
+		WithLatencyThreshold(100*time.Millisecond),
+		WithLatencyBreakerMinRequestThreshold(1),
+		WithLatencyBreakerSleepWindow(2*time.Second),
+		WithLatencyBreakerClock(clock))
+
+	breaker.Latency(300 * time.Millisecond)
+	breaker.Success()
+	if pass, _ := breaker.Allow(); pass {
+		t.Fatalf("LatencyBreaker.Allow() got = %v, want %v", pass, false)
+	}
+
+	// 开启期间请求持续走降级逻辑。
+	for i := 0; i < 4; i++ {
+		clock.Advance(500 * time.Millisecond)
+		breaker.FallbackSuccess()
+	}
+
+	// 休眠时间已过，读取统计数据不会进入半开状态。
+	for i := 0; i < 3; i++ {
+		if status := breaker.Summary().Status; status != "open" {
+			t.Errorf("LatencyBreaker.Summary() Status got = %v, want %v", status, "open")
+		}
+	}
+	if pass, statusMsg := breaker.Allow(); !pass || statusMsg != "half-open" {
+		t.Errorf("LatencyBreaker.Allow() got = %v/%v, want %v/%v", pass, statusMsg, true, "half-open")
+	}
+}
+
+// TestLatencyBreaker_halfOpenFastFailure 测试半开状态的尝试请求耗时很短但执行失败时，熔断器重新开启而不是关闭。
+func TestLatencyBreaker_halfOpenFastFailure(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := NewLatencyBreaker("test",
+		WithLatencyThreshold(100*time.Millisecond),
+		WithLatencyBreakerMinRequestThreshold(1),
+		WithLatencyBreakerSleepWindow(time.Second),
+		WithLatencyBreakerClock(clock))
+
+	breaker.Latency(300 * time.Millisecond)
+	breaker.Success()
+	breaker.Allow() // 开启。
+
+	clock.Advance(2 * time.Second)
+	if pass, statusMsg := breaker.Allow(); !pass || statusMsg != "half-open" {
+		t.Fatalf("LatencyBreaker.Allow() got = %v/%v, want %v/%v", pass, statusMsg, true, "half-open")
+	}
+	// 与 Command 一样先记录耗时，再记录执行结果。
+	breaker.Latency(time.Millisecond)
+	breaker.Failure()
+	if state := breaker.State(); state != StateOpen {
+		t.Errorf("LatencyBreaker.State() got = %v, want %v", state, StateOpen)
+	}
+	if pass, statusMsg := breaker.Allow(); pass || statusMsg != "open" {
+		t.Errorf("LatencyBreaker.Allow() got = %v/%v, want %v/%v", pass, statusMsg, false, "open")
+	}
+}
+
+// TestLatencyBreaker_concurrentTrip 测试并发判断时熔断器开启，休眠时间结束前不会放行任何请求（需要 -race 运行）。
+func TestLatencyBreaker_concurrentTrip(t *testing.T) {
+	t.Parallel()
+	for round := 0; round < 50; round++ {
+		clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)} // 时间不推进，休眠时间不会结束。
+		breaker := NewLatencyBreaker("test",
+			WithLatencyThreshold(100*time.Millisecond),
+			WithLatencyBreakerMinRequestThreshold(1),
+			WithLatencyBreakerSleepWindow(time.Second),
+			WithLatencyBreakerClock(clock))
+		breaker.Latency(300 * time.Millisecond)
+		breaker.Success() // 下一次判断就会开启。
+
+		var wg sync.WaitGroup
+		var admitted int64
+		start := make(chan struct{})
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				for j := 0; j < 20; j++ {
+					if pass, _ := breaker.Allow(); pass {
+						atomic.AddInt64(&admitted, 1)
+					}
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+		if admitted != 0 {
+			t.Fatalf("round %d: LatencyBreaker.Allow() admitted %d requests before sleep window, want 0", round, admitted)
+		}
+	}
+}
+
+// TestLatencyBreaker_halfOpenLateCall 测试开启前放行、半开期间才返回的请求不会影响尝试请求自己的耗时判断。
+func TestLatencyBreaker_halfOpenLateCall(t *testing.T) {
+	t.Parallel()
+	newHalfOpenBreaker := func() (*latencyBreaker, *fakeClock) {
+		clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+		breaker := NewLatencyBreaker("test",
+			WithLatencyThreshold(100*time.Millisecond),
+			WithLatencyBreakerMinRequestThreshold(1),
+			WithLatencyBreakerSleepWindow(time.Second),
+			WithLatencyBreakerClock(clock))
+		breaker.Latency(300 * time.Millisecond)
+		breaker.Success()
+		breaker.Allow() // 开启。
+		clock.Advance(time.Second)
+		if pass, statusMsg := breaker.Allow(); !pass || statusMsg != "half-open" {
+			t.Fatalf("LatencyBreaker.Allow() got = %v/%v, want %v/%v", pass, statusMsg, true, "half-open")
+		}
+		return breaker, clock
+	}
+
+	// 迟到的慢请求在尝试请求的耗时与结果之间返回，快速的尝试请求依然关闭熔断器。
+	breaker, clock := newHalfOpenBreaker()
+	clock.Advance(10 * time.Millisecond)
+	breaker.Latency(10 * time.Millisecond) // 尝试请求记录耗时。
+	breaker.Latency(5 * time.Second)       // 开启前放行的请求返回。
+	breaker.Success()
+	breaker.Success() // 尝试请求记录结果。
+	if state := breaker.State(); state != StateClosed {
+		t.Errorf("LatencyBreaker.State() with interleaved late call got = %v, want %v", state, StateClosed)
+	}
+
+	// 迟到的请求先于尝试请求返回，不做决定，等尝试请求的结果。
+	breaker, clock = newHalfOpenBreaker()
+	clock.Advance(10 * time.Millisecond)
+	breaker.Latency(2 * time.Second)
+	breaker.Success()
+	if state := breaker.State(); state != StateHalfOpen {
+		t.Errorf("LatencyBreaker.State() after late call got = %v, want %v", state, StateHalfOpen)
+	}
+	clock.Advance(290 * time.Millisecond)
+	breaker.Latency(300 * time.Millisecond) // 尝试请求很慢。
+	breaker.Success()
+	if state := breaker.State(); state != StateOpen {
+		t.Errorf("LatencyBreaker.State() after slow probe got = %v, want %v", state, StateOpen)
+	}
+}
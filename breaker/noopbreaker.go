@@ -0,0 +1,100 @@
+package breaker
+
+import (
+	"time"
+
+	"github.com/bunnier/circuit/breaker/internal"
+)
+
+var _ Breaker = (*noopBreaker)(nil)
+var _ Configurable = (*noopBreaker)(nil)
+
+// noopBreaker 是 Breaker 的一种实现，永远不会熔断。
+type noopBreaker struct {
+	name   string           // 名称。
+	metric *internal.Metric // 执行情况统计数据。
+}
+
+// NewNoopBreaker 用于新建一个 NoopBreaker 熔断器。
+// NoopBreaker 放行所有请求，状态永远是关闭，手动强制状态也不生效，但依然记录统计数据，Summary 可以正常使用。
+// 主要用于压测等需要保留Command的超时、统计等功能，但不希望熔断的场景。
+func NewNoopBreaker(name string) *noopBreaker {
+	return &noopBreaker{
+		name:   name,
+		metric: internal.NewMetric(internal.WithMetricTimeWindow(time.Second * 5)),
+	}
+}
+
+// Allow 用于判断断路器是否允许通过请求，总是放行。
+func (b *noopBreaker) Allow() (bool, string) {
+	return true, "disabled"
+}
+
+// Success 用于记录成功事件。
+func (b *noopBreaker) Success() {
+	b.metric.Success()
+}
+
+// Failure 用于记录失败事件。
+func (b *noopBreaker) Failure() {
+	b.metric.Failure()
+}
+
+// Timeout 用于记录超时事件。
+func (b *noopBreaker) Timeout() {
+	b.metric.Timeout()
+}
+
+// Latency 记录一次功能函数执行耗时。
+func (b *noopBreaker) Latency(d time.Duration) {
+	b.metric.Latency(d)
+}
+
+// FallbackSuccess 记录一次降级函数执行成功事件。
+func (b *noopBreaker) FallbackSuccess() {
+	b.metric.FallbackSuccess()
+}
+
+// FallbackFailure 记录一次降级函数执行失败事件。
+func (b *noopBreaker) FallbackFailure() {
+	b.metric.FallbackFailure()
+}
+
+// Summary 返回当前健康状态。
+func (b *noopBreaker) Summary() *BreakerSummary {
+	return newBreakerSummary(b.name, "disabled", b.metric.Summary(), 1)
+}
+
+// Drain 返回当前健康状态，并同时重置上报用的统计数据。
+func (b *noopBreaker) Drain() *BreakerSummary {
+	return newBreakerSummary(b.name, "disabled", b.metric.Drain(), 1)
+}
+
+// LatencyBuckets 返回滑动窗口中按时间分布的耗时直方图。
+func (b *noopBreaker) LatencyBuckets() [][]int64 {
+	return b.metric.LatencyBuckets()
+}
+
+// State 返回熔断器当前状态，总是关闭。
+func (b *noopBreaker) State() State {
+	return StateClosed
+}
+
+// Reset 用于清空统计数据。
+func (b *noopBreaker) Reset() {
+	b.metric.Reset()
+}
+
+// Config 返回熔断器的有效配置。
+func (b *noopBreaker) Config() BreakerConfig {
+	return BreakerConfig{Type: "noop", Options: map[string]interface{}{}}
+}
+
+// ForceOpen 不生效。
+func (b *noopBreaker) ForceOpen() {}
+
+// ForceClose 不生效。
+func (b *noopBreaker) ForceClose() {}
+
+// ClearForced 不生效。
+func (b *noopBreaker) ClearForced() {}
@@ -0,0 +1,26 @@
+package breaker
+
+import "testing"
+
+func TestNoopBreaker(t *testing.T) {
+	t.Parallel()
+	b := NewNoopBreaker("test")
+	b.ForceOpen() // 强制状态也不生效。
+
+	for i := 0; i < 100; i++ {
+		if pass, status := b.Allow(); !pass || status != "disabled" {
+			t.Fatalf("NoopBreaker.Allow() got = %v, %s, want %v, %s", pass, status, true, "disabled")
+		}
+		b.Failure()
+	}
+	if state := b.State(); state != StateClosed {
+		t.Errorf("NoopBreaker.State() got = %v, want %v", state, StateClosed)
+	}
+
+	// 统计数据依然正常记录。
+	summary := b.Summary()
+	if summary.Failure != 100 || summary.ErrorPercentage != 100 || summary.Status != "disabled" {
+		t.Errorf("NoopBreaker.Summary() got = %d/%v/%s, want %d/%v/%s",
+			summary.Failure, summary.ErrorPercentage, summary.Status, 100, 100, "disabled")
+	}
+}
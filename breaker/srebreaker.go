@@ -6,12 +6,14 @@ import (
 	"math"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bunnier/circuit/breaker/internal"
 )
 
 var _ Breaker = (*sreBreaker)(nil)
+var _ Configurable = (*sreBreaker)(nil)
 
 // sreBreaker 是 Breaker 的一种实现。
 type sreBreaker struct {
@@ -20,12 +22,20 @@ type sreBreaker struct {
 	name   string           // 名称。
 	metric *internal.Metric // 执行情况统计数据。
 
-	k float64 // 算法的调节系数。
+	k             float64 // 算法的调节系数。
+	timeoutWeight float64 // 每次超时按多少次失败计算。
+	minRequests   int64   // 熔断器生效必须满足的最小流量，窗口内请求数量不足时不拒绝。
+
+	fallbackAsAccept bool                         // 是否将降级函数执行成功也计入accepts。
+	acceptsFunc      func(*MetricSummary) float64 // 自定义accepts的计算方法（可选），设置后fallbackAsAccept不再生效。
+
+	forcedStatus int32 // 手动强制状态，优先于概率判断。
 
 	rand     *rand.Rand // 随机数生成器。
 	randLock sync.Mutex // 用于控制随机数生成时候的并发。
 
 	timeWindow time.Duration // 滑动窗口的大小。
+	clock      Clock         // 统计数据使用的时间源（可选）。
 }
 
 // NewSreBreaker 用于新建一个 SreBreaker 熔断器。
@@ -36,9 +46,10 @@ func NewSreBreaker(name string, options ...SreBreakerOption) *sreBreaker {
 		ctx:  context.Background(),
 		name: name,
 
-		k:        2, // 算法的调节系数，越高算法越懒惰，反之越主动。
-		rand:     rand.New(rand.NewSource(time.Now().Unix())),
-		randLock: sync.Mutex{},
+		k:             2, // 算法的调节系数，越高算法越懒惰，反之越主动。
+		timeoutWeight: 1, // 默认超时与其它失败一样。
+		rand:          rand.New(rand.NewSource(time.Now().Unix())),
+		randLock:      sync.Mutex{},
 
 		timeWindow: time.Minute * 2,
 	}
@@ -48,11 +59,14 @@ func NewSreBreaker(name string, options ...SreBreakerOption) *sreBreaker {
 	}
 
 	// 初始化选项后，根据选项初始化Metric。
-	b.metric = internal.NewMetric(
+	metricOptions := []internal.MerticOption{
 		internal.WithMetricTimeWindow(b.timeWindow),
-		internal.WithMetricMetricInterval(time.Second*30),
-		internal.WithMetricContext(b.ctx),
-	)
+		internal.WithMetricMetricInterval(time.Second * 30),
+	}
+	if b.clock != nil {
+		metricOptions = append(metricOptions, internal.WithMetricClock(b.clock))
+	}
+	b.metric = internal.NewMetric(metricOptions...)
 
 	return b
 }
@@ -67,6 +81,10 @@ func (b *sreBreaker) Allow() (bool, string) {
 // Allow 用于判断断路器是否允许通过请求。
 // 第一返回值：true能通过/false不能；第二返回值：当前Breaker状态的文字描述。
 func (b *sreBreaker) allow(summary *internal.MetricSummary) (bool, string) {
+	if pass, statusMsg, forced := forcedAllow(&b.forcedStatus); forced {
+		return pass, statusMsg // 手动强制状态优先于自动判断。
+	}
+
 	b.randLock.Lock()
 	currentProb := b.rand.Float64() // 计算本次概率。
 	b.randLock.Unlock()
@@ -77,12 +95,37 @@ func (b *sreBreaker) allow(summary *internal.MetricSummary) (bool, string) {
 }
 
 // getRejectionProbability 用于计算当前请求的熔断概率。
+// 公式为 max(0, (requests - K*accepts) / (requests + 1))，其中accepts为成功数量（设置了fallbackAsAccept时再加上降级函数执行成功数量，设置了acceptsFunc时由其计算），
+// requests = Total + (timeoutWeight-1)*Timeout，即每次超时按timeoutWeight次失败计算。
+// 窗口内请求数量不足minRequests，或K*accepts大于等于requests时（包括没有任何请求的冷启动阶段）概率为0，即窗口内积累到足够的失败前不会拒绝请求；
+// 全部失败时概率为requests/(requests+1)，随流量增大趋近于1，但永远小于1，仍会放行少量请求用于探测。
 func (b *sreBreaker) getRejectionProbability(summary *internal.MetricSummary) float64 {
+	if summary.Total < b.minRequests { // 流量太小时几次失败就会拒绝请求，没有满足最小流量要求前不拒绝。
+		return 0
+	}
+
 	// 算法参考：https://sre.google/sre-book/handling-overload/#eq2101
-	prob := (float64(summary.Total) - b.k*float64(summary.Success)) / float64(summary.Total+1)
+	requests := float64(summary.Total) + (b.timeoutWeight-1)*float64(summary.Timeout) // Total中已经包含了一次超时。
+	accepts := float64(summary.Success)
+	if b.acceptsFunc != nil {
+		accepts = b.acceptsFunc(summary)
+	} else if b.fallbackAsAccept {
+		accepts += float64(summary.FallbackSuccess)
+	}
+	// 请求数超过float64能精确表示的范围后，requests+1与requests相等，全部失败时概率会变成1，拒绝所有请求。
+	// 这里按比例缩小requests与accepts，只影响公式中的+1项，保证概率依然小于1。
+	if requests > maxExactRequests {
+		scale := maxExactRequests / requests
+		requests *= scale
+		accepts *= scale
+	}
+	prob := (requests - b.k*accepts) / (requests + 1)
 	return math.Max(0, prob)
 }
 
+// maxExactRequests 是计算熔断概率时requests的上限，在此范围内requests+1可以被float64精确表示。
+const maxExactRequests float64 = 1 << 52
+
 // Success 用于记录成功事件。
 func (b *sreBreaker) Success() {
 	b.metric.Success()
@@ -98,6 +141,11 @@ func (b *sreBreaker) Timeout() {
 	b.metric.Timeout()
 }
 
+// Latency 记录一次功能函数执行耗时。
+func (b *sreBreaker) Latency(d time.Duration) {
+	b.metric.Latency(d)
+}
+
 // FallbackSuccess 记录一次降级函数执行成功事件。
 func (b *sreBreaker) FallbackSuccess() {
 	b.metric.FallbackSuccess()
@@ -105,28 +153,77 @@ func (b *sreBreaker) FallbackSuccess() {
 
 // FallbackFailure 记录一次降级函数执行失败事件。
 func (b *sreBreaker) FallbackFailure() {
-	b.metric.FallbackSuccess()
+	b.metric.FallbackFailure()
 }
 
 // Summary 返回当前健康状态。
 func (b *sreBreaker) Summary() *BreakerSummary {
 	summary := b.metric.Summary() // 当前健康统计。
-	return &BreakerSummary{
-		Status:               fmt.Sprintf("current rejection probability: %3.3f", b.getRejectionProbability(summary)), // 直接显示概率
-		TimeWindowSecond:     summary.TimeWindowSecond,
-		MetricIntervalSecond: summary.MetricIntervalSecond,
-		Success:              summary.Success,
-		Timeout:              summary.Timeout,
-		Failure:              summary.Failure,
-		FallbackSuccess:      summary.FallbackSuccess,
-		FallbackFailure:      summary.FallbackFailure,
-		Total:                summary.Total,
-		ErrorPercentage:      summary.ErrorPercentage,
-		LastExecuteTime:      summary.LastExecuteTime,
-		LastSuccessTime:      summary.LastSuccessTime,
-		LastTimeoutTime:      summary.LastTimeoutTime,
-		LastFailureTime:      summary.LastFailureTime,
+	return newBreakerSummary(b.name, b.status(summary), summary, b.minRequests)
+}
+
+// Drain 返回当前健康状态，并同时重置上报用的统计数据。
+func (b *sreBreaker) Drain() *BreakerSummary {
+	summary := b.metric.Drain()
+	return newBreakerSummary(b.name, b.status(summary), summary, b.minRequests)
+}
+
+// status 返回当前状态的文字描述，直接显示熔断概率。
+func (b *sreBreaker) status(summary *internal.MetricSummary) string {
+	if _, statusMsg, forced := forcedAllow(&b.forcedStatus); forced {
+		return statusMsg
 	}
+	return fmt.Sprintf("current rejection probability: %3.3f", b.getRejectionProbability(summary))
+}
+
+// LatencyBuckets 返回滑动窗口中按时间分布的耗时直方图。
+func (b *sreBreaker) LatencyBuckets() [][]int64 {
+	return b.metric.LatencyBuckets()
+}
+
+// State 返回熔断器当前状态，熔断概率大于0时视为开启。
+func (b *sreBreaker) State() State {
+	if isForced(&b.forcedStatus) {
+		return StateForced
+	}
+	if b.getRejectionProbability(b.metric.Summary()) > 0 {
+		return StateOpen
+	}
+	return StateClosed
+}
+
+// Reset 用于清空统计数据，熔断概率随之归零。
+func (b *sreBreaker) Reset() {
+	b.metric.Reset()
+}
+
+// Config 返回熔断器的有效配置。
+func (b *sreBreaker) Config() BreakerConfig {
+	return BreakerConfig{
+		Type: "sre",
+		Options: map[string]interface{}{
+			"timeWindow":       b.timeWindow,
+			"k":                b.k,
+			"timeoutWeight":    b.timeoutWeight,
+			"minRequests":      b.minRequests,
+			"fallbackAsAccept": b.fallbackAsAccept,
+		},
+	}
+}
+
+// ForceOpen 用于手动强制开启熔断器，强制期间拒绝所有请求。
+func (b *sreBreaker) ForceOpen() {
+	atomic.StoreInt32(&b.forcedStatus, forcedOpen)
+}
+
+// ForceClose 用于手动强制关闭熔断器，强制期间放行所有请求。
+func (b *sreBreaker) ForceClose() {
+	atomic.StoreInt32(&b.forcedStatus, forcedClosed)
+}
+
+// ClearForced 用于清除手动强制状态，恢复熔断器的自动判断。
+func (b *sreBreaker) ClearForced() {
+	atomic.StoreInt32(&b.forcedStatus, forcedNone)
 }
 
 // SreBreakerOption 是 SreBreaker 的可选项。
@@ -139,6 +236,13 @@ func WithSreBreakerTimeWindow(timeWindow time.Duration) SreBreakerOption {
 	}
 }
 
+// WithSreBreakerClock 设置统计数据使用的时间源（默认系统时间），用于在测试中驱动滑动窗口。
+func WithSreBreakerClock(clock Clock) SreBreakerOption {
+	return func(b *sreBreaker) {
+		b.clock = clock
+	}
+}
+
 // WithSreBreakerContext 设置用于释放资源的context。
 func WithSreBreakerContext(ctx context.Context) SreBreakerOption {
 	return func(b *sreBreaker) {
@@ -152,3 +256,42 @@ func WithSreBreakerK(k float64) SreBreakerOption {
 		b.k = k
 	}
 }
+
+// WithSreBreakerRandSource 设置判断是否放行时使用的随机数源（默认以当前时间为种子），传入固定种子可以得到可重现的结果，主要用于测试。
+func WithSreBreakerRandSource(src rand.Source) SreBreakerOption {
+	return func(b *sreBreaker) {
+		b.rand = rand.New(src)
+	}
+}
+
+// WithSreBreakerTimeoutWeight 设置每次超时按多少次失败计算（默认1），大于1时超时会更快地提高熔断概率。
+func WithSreBreakerTimeoutWeight(w float64) SreBreakerOption {
+	return func(b *sreBreaker) {
+		b.timeoutWeight = w
+	}
+}
+
+// WithSreBreakerMinRequests 设置熔断器生效必须满足的最小流量（默认0，不限制），与 CutBreaker 的 minRequestThreshold 相同。
+// 窗口内请求数量不足n时放行所有请求，避免低流量的服务因为几次失败就被拒绝。
+func WithSreBreakerMinRequests(n int64) SreBreakerOption {
+	return func(b *sreBreaker) {
+		b.minRequests = n
+	}
+}
+
+// WithSreBreakerAcceptsFunc 设置公式中accepts的计算方法（默认为成功数量，见 WithSreBreakerFallbackAsAccept）。
+// 论文中的accepts是后端接受了的请求，与成功不一定相同，如只把超时视为没有被接受，其它失败也算被后端接受：
+// func(s *MetricSummary) float64 { return float64(s.RequestTotal - s.Timeout) }。设置后 WithSreBreakerFallbackAsAccept 不再生效。
+func WithSreBreakerAcceptsFunc(acceptsFunc func(summary *MetricSummary) float64) SreBreakerOption {
+	return func(b *sreBreaker) {
+		b.acceptsFunc = acceptsFunc
+	}
+}
+
+// WithSreBreakerFallbackAsAccept 设置是否将降级函数执行成功也计入公式中的accepts（默认否）。
+// 降级成功同样没有给后端带来压力，计入后熔断概率会更低。
+func WithSreBreakerFallbackAsAccept(fallbackAsAccept bool) SreBreakerOption {
+	return func(b *sreBreaker) {
+		b.fallbackAsAccept = fallbackAsAccept
+	}
+}
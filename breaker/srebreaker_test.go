@@ -3,6 +3,8 @@ package breaker
 import (
 	"fmt"
 	"math"
+	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
@@ -124,3 +126,343 @@ func TestSreBreaker_allow(t *testing.T) {
 		})
 	}
 }
+
+// TestSreBreaker_forced 测试手动强制状态优先于概率判断。
+func TestSreBreaker_forced(t *testing.T) {
+	t.Parallel()
+	breaker := NewSreBreaker("test", WithSreBreakerK(1.5))
+
+	healthy := &internal.MetricSummary{Success: 100, Total: 100}
+	unhealthy := &internal.MetricSummary{Failure: 100, Total: 100, ErrorPercentage: 100}
+
+	const testCount int = 1000 // 测试次数。
+
+	// 强制开启时，熔断概率为0也全部拒绝。
+	breaker.ForceOpen()
+	for i := 0; i < testCount; i++ {
+		if pass, statusMsg := breaker.allow(healthy); pass || statusMsg != "forced-open" {
+			t.Fatalf("SreBreaker.allow() got = %v, %v, want %v, %v", pass, statusMsg, false, "forced-open")
+		}
+	}
+	if summary := breaker.Summary(); summary.Status != "forced-open" {
+		t.Errorf("SreBreaker.Summary() Status got = %v, want %v", summary.Status, "forced-open")
+	}
+
+	// 强制关闭时，全部失败也全部放行。
+	breaker.ForceClose()
+	for i := 0; i < testCount; i++ {
+		if pass, statusMsg := breaker.allow(unhealthy); !pass || statusMsg != "forced-closed" {
+			t.Fatalf("SreBreaker.allow() got = %v, %v, want %v, %v", pass, statusMsg, true, "forced-closed")
+		}
+	}
+
+	// 清除强制状态后恢复概率判断，全部失败时应该大部分被拒绝。
+	breaker.ClearForced()
+	rejectCount := 0
+	for i := 0; i < testCount; i++ {
+		if pass, _ := breaker.allow(unhealthy); !pass {
+			rejectCount++
+		}
+	}
+	if rejectCount < testCount*9/10 {
+		t.Errorf("SreBreaker.allow() reject count = %v, want more than %v", rejectCount, testCount*9/10)
+	}
+}
+
+// TestSreBreaker_State 测试熔断器状态的类型化表示。
+func TestSreBreaker_State(t *testing.T) {
+	t.Parallel()
+	breaker := NewSreBreaker("test", WithSreBreakerK(1.5))
+
+	if state := breaker.State(); state != StateClosed {
+		t.Errorf("SreBreaker.State() got = %v, want %v", state, StateClosed)
+	}
+
+	for i := 0; i < 10; i++ {
+		breaker.Failure()
+	}
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+	if state := breaker.State(); state != StateOpen {
+		t.Errorf("SreBreaker.State() got = %v, want %v", state, StateOpen)
+	}
+
+	breaker.ForceClose()
+	if state := breaker.State(); state != StateForced {
+		t.Errorf("SreBreaker.State() got = %v, want %v", state, StateForced)
+	}
+}
+
+// fakeClock 是用于测试的 Clock 实现，只有手动推进时时间才会变化。
+type fakeClock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// Advance 用于将时间向后推进d。
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// TestSreBreaker_clock 通过可控的时间源推进2分钟的滑动窗口，测试滑出窗口的结果不再影响熔断概率。
+func TestSreBreaker_clock(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := NewSreBreaker("test", WithSreBreakerK(1.5), WithSreBreakerClock(clock))
+
+	validate := func(name string, success, failure int64, state State) {
+		time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+		summary := breaker.Summary()
+		if summary.Success != success || summary.Failure != failure {
+			t.Errorf("%s: SreBreaker.Summary() got = %d/%d, want %d/%d", name, summary.Success, summary.Failure, success, failure)
+		}
+		if got := breaker.State(); got != state {
+			t.Errorf("%s: SreBreaker.State() got = %v, want %v", name, got, state)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		breaker.Failure()
+	}
+	validate("failures", 0, 10, StateOpen)
+
+	// 1分钟后的成功请求，失败依然在窗口内：(20-1.5*10)/21 > 0。
+	clock.Advance(time.Minute)
+	for i := 0; i < 10; i++ {
+		breaker.Success()
+	}
+	validate("within window", 10, 10, StateOpen)
+
+	// 再过1分30秒，失败已经滑出窗口，只剩下成功请求。
+	clock.Advance(time.Minute + time.Second*30)
+	validate("failures expired", 10, 0, StateClosed)
+
+	// 再过1分钟，所有结果都滑出窗口。
+	clock.Advance(time.Minute)
+	validate("all expired", 0, 0, StateClosed)
+}
+
+// TestSreBreaker_timeoutWeight 测试相同统计数据下，超时权重越大熔断概率越高。
+func TestSreBreaker_timeoutWeight(t *testing.T) {
+	t.Parallel()
+	summary := &internal.MetricSummary{
+		Success: 50,
+		Timeout: 20,
+		Failure: 50,
+		Total:   100,
+	}
+	tests := []struct {
+		name   string
+		weight float64
+		prob   float64
+	}{
+		{"weight 1.0", 1, 0},                 // (100-2*50)/101。
+		{"weight 2.0", 2, float64(20) / 121}, // (120-2*50)/121。
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewSreBreaker("test", WithSreBreakerTimeoutWeight(tt.weight))
+			if got := b.getRejectionProbability(summary); math.Abs(got-tt.prob) > 1e-9 {
+				t.Errorf("SreBreaker.getRejectionProbability() got = %v, want %v", got, tt.prob)
+			}
+		})
+	}
+}
+
+// TestSreBreaker_randSource 测试固定随机数源时，放行与拒绝的顺序是确定的。
+func TestSreBreaker_randSource(t *testing.T) {
+	t.Parallel()
+	summary := &internal.MetricSummary{Success: 25, Failure: 75, Total: 100} // 熔断概率为(100-2*25)/101。
+	b := NewSreBreaker("test", WithSreBreakerRandSource(rand.NewSource(1)))
+
+	want := []bool{true, true, true, false, false, true, false, false, false, false}
+	for i, pass := range want {
+		if got, _ := b.allow(summary); got != pass {
+			t.Errorf("SreBreaker.allow() #%d got = %v, want %v", i, got, pass)
+		}
+	}
+}
+
+// TestSreBreaker_rejectionProbabilityEdges 测试零流量、全部成功、全部失败及超大流量时的熔断概率。
+func TestSreBreaker_rejectionProbabilityEdges(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		summary *internal.MetricSummary
+		prob    float64
+	}{
+		{"zero traffic", &internal.MetricSummary{}, 0},                        // 冷启动阶段不拒绝。
+		{"all success", &internal.MetricSummary{Success: 100, Total: 100}, 0}, // K*accepts超过requests，截断为0。
+		{"k*success exceeds total", &internal.MetricSummary{Success: 60, Failure: 40, Total: 100}, 0},
+		{"all failure", &internal.MetricSummary{Failure: 100, Total: 100}, float64(100) / 101},
+		{"huge total", &internal.MetricSummary{Failure: 1 << 50, Total: 1 << 50}, float64(1<<50) / (1<<50 + 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewSreBreaker("test")
+			got := b.getRejectionProbability(tt.summary)
+			if math.Abs(got-tt.prob) > 1e-9 {
+				t.Errorf("SreBreaker.getRejectionProbability() got = %v, want %v", got, tt.prob)
+			}
+			if got < 0 || got >= 1 {
+				t.Errorf("SreBreaker.getRejectionProbability() got = %v, want in [0, 1)", got)
+			}
+		})
+	}
+}
+
+// TestSreBreaker_fallbackAsAccept 测试相同的后端失败率下，将降级成功计入accepts会降低熔断概率。
+func TestSreBreaker_fallbackAsAccept(t *testing.T) {
+	t.Parallel()
+	summary := &internal.MetricSummary{
+		Success:         20,
+		Failure:         80,
+		FallbackSuccess: 30,
+		Total:           100,
+	}
+	tests := []struct {
+		name             string
+		fallbackAsAccept bool
+		prob             float64
+	}{
+		{"exclude fallback", false, float64(60) / 101}, // (100-2*20)/101。
+		{"include fallback", true, 0},                  // (100-2*(20+30))/101。
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewSreBreaker("test", WithSreBreakerFallbackAsAccept(tt.fallbackAsAccept))
+			if got := b.getRejectionProbability(summary); math.Abs(got-tt.prob) > 1e-9 {
+				t.Errorf("SreBreaker.getRejectionProbability() got = %v, want %v", got, tt.prob)
+			}
+		})
+	}
+
+	// 降级函数执行失败不计入。
+	b := NewSreBreaker("test", WithSreBreakerFallbackAsAccept(true))
+	b.FallbackFailure()
+	if summary := b.Summary(); summary.FallbackSuccess != 0 || summary.FallbackFailure != 1 {
+		t.Errorf("SreBreaker.Summary() got = %d/%d, want %d/%d", summary.FallbackSuccess, summary.FallbackFailure, 0, 1)
+	}
+}
+
+// TestSreBreaker_minRequests 测试没有满足最小流量要求前不拒绝请求，满足后恢复按概率拒绝。
+func TestSreBreaker_minRequests(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		summary *internal.MetricSummary
+		prob    float64
+	}{
+		{"below floor", &internal.MetricSummary{Failure: 9, Total: 9}, 0}, // 全部失败也不拒绝。
+		{"at floor", &internal.MetricSummary{Failure: 10, Total: 10}, float64(10) / 11},
+		{"above floor", &internal.MetricSummary{Success: 5, Failure: 15, Total: 20}, float64(10) / 21},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewSreBreaker("test", WithSreBreakerMinRequests(10))
+			if got := b.getRejectionProbability(tt.summary); math.Abs(got-tt.prob) > 1e-9 {
+				t.Errorf("SreBreaker.getRejectionProbability() got = %v, want %v", got, tt.prob)
+			}
+		})
+	}
+
+	// 低于最小流量时，无论随机数如何都放行。
+	b := NewSreBreaker("test", WithSreBreakerMinRequests(10), WithSreBreakerRandSource(rand.NewSource(1)))
+	for i := 0; i < 9; i++ {
+		b.Failure()
+	}
+	for i := 0; i < 100; i++ {
+		if pass, _ := b.Allow(); !pass {
+			t.Fatalf("SreBreaker.Allow() below floor got = %v, want %v", pass, true)
+		}
+	}
+	if state := b.State(); state != StateClosed {
+		t.Errorf("SreBreaker.State() below floor got = %v, want %v", state, StateClosed)
+	}
+
+	// 达到最小流量后恢复按概率拒绝。
+	b.Failure()
+	rejected := 0
+	for i := 0; i < 100; i++ {
+		if pass, _ := b.Allow(); !pass {
+			rejected++
+		}
+	}
+	if rejected == 0 {
+		t.Errorf("SreBreaker.Allow() above floor rejected = %d, want > 0", rejected)
+	}
+	if state := b.State(); state != StateOpen {
+		t.Errorf("SreBreaker.State() above floor got = %v, want %v", state, StateOpen)
+	}
+}
+
+// TestSreBreaker_acceptsFunc 测试自定义accepts的计算方法，只把超时视为没有被后端接受。
+func TestSreBreaker_acceptsFunc(t *testing.T) {
+	t.Parallel()
+	summary := &internal.MetricSummary{
+		Success:         20,
+		Failure:         80, // 其中30次为超时。
+		Timeout:         30,
+		FallbackSuccess: 30,
+		RequestTotal:    100,
+		Total:           100,
+	}
+	acceptsFunc := func(s *MetricSummary) float64 {
+		return float64(s.RequestTotal - s.Timeout)
+	}
+
+	tests := []struct {
+		name    string
+		options []SreBreakerOption
+		prob    float64
+	}{
+		{"default", nil, float64(60) / 101},                                       // (100-2*20)/101。
+		{"custom", []SreBreakerOption{WithSreBreakerAcceptsFunc(acceptsFunc)}, 0}, // (100-2*70)/101。
+		{"custom ignores fallbackAsAccept", []SreBreakerOption{
+			WithSreBreakerFallbackAsAccept(true),
+			WithSreBreakerK(1.2),
+			WithSreBreakerAcceptsFunc(acceptsFunc),
+		}, float64(16) / 101}, // (100-1.2*70)/101。
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewSreBreaker("test", tt.options...)
+			if got := b.getRejectionProbability(summary); math.Abs(got-tt.prob) > 1e-9 {
+				t.Errorf("SreBreaker.getRejectionProbability() got = %v, want %v", got, tt.prob)
+			}
+		})
+	}
+}
+
+// TestSreBreaker_hugeTotal 测试接近int64上限的请求数时，熔断概率不会出现NaN或因精度丢失变成1。
+func TestSreBreaker_hugeTotal(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		summary *internal.MetricSummary
+		prob    float64
+	}{
+		{"all failure", &internal.MetricSummary{Failure: math.MaxInt64, Total: math.MaxInt64}, 1},
+		{"quarter success", &internal.MetricSummary{Success: math.MaxInt64 / 4, Failure: math.MaxInt64 / 4 * 3, Total: math.MaxInt64}, 0.5},
+		{"weighted timeout", &internal.MetricSummary{Timeout: math.MaxInt64, Failure: math.MaxInt64, Total: math.MaxInt64}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewSreBreaker("test", WithSreBreakerTimeoutWeight(2))
+			got := b.getRejectionProbability(tt.summary)
+			if math.IsNaN(got) || got < 0 || got >= 1 {
+				t.Errorf("SreBreaker.getRejectionProbability() got = %v, want in [0, 1)", got)
+			}
+			if math.Abs(got-tt.prob) > 1e-9 {
+				t.Errorf("SreBreaker.getRejectionProbability() got = %v, want about %v", got, tt.prob)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package breaker
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bunnier/circuit/breaker/internal"
+)
+
+var _ Breaker = (*tokenBucketBreaker)(nil)
+var _ Configurable = (*tokenBucketBreaker)(nil)
+
+// tokenBucketBreaker 是 Breaker 的一种实现。
+type tokenBucketBreaker struct {
+	name   string           // 名称。
+	metric *internal.Metric // 执行情况统计数据。
+
+	forcedStatus int32 // 手动强制状态，优先于令牌判断。
+
+	lock       sync.Mutex // 用于控制以下字段的并发。
+	tokens     float64    // 当前剩余的令牌数量。
+	lastRefill time.Time  // 最后一次补充令牌的时间。
+
+	rate       float64       // 每秒补充的令牌数量。
+	burst      int64         // 令牌桶的容量，即允许的最大突发请求数量。
+	timeWindow time.Duration // 滑动窗口的大小（单位秒1-60）。
+	clock      Clock         // 补充令牌与统计数据使用的时间源（可选）。
+}
+
+// NewTokenBucketBreaker 用于新建一个 TokenBucketBreaker 熔断器。
+// TokenBucketBreaker 是基于令牌桶的客户端限流器：每秒补充rate个令牌，最多积累burst个，每次放行消耗一个令牌，没有令牌时拒绝请求。
+// 记录的执行结果不影响是否放行，只用于统计，Summary 可以正常使用。
+func NewTokenBucketBreaker(name string, options ...TokenBucketBreakerOption) *tokenBucketBreaker {
+	b := &tokenBucketBreaker{
+		name:       name,
+		rate:       100,
+		burst:      100,
+		timeWindow: time.Second * 5,
+	}
+
+	for _, option := range options {
+		option(b)
+	}
+
+	// 初始化选项后，根据选项初始化Metric。
+	metricOptions := []internal.MerticOption{
+		internal.WithMetricTimeWindow(b.timeWindow),
+	}
+	if b.clock != nil {
+		metricOptions = append(metricOptions, internal.WithMetricClock(b.clock))
+	}
+	b.metric = internal.NewMetric(metricOptions...)
+	b.tokens = float64(b.burst) // 初始时令牌桶是满的。
+	b.lastRefill = b.now()
+
+	return b
+}
+
+// Allow 用于判断断路器是否允许通过请求，有令牌时消耗一个令牌并放行。
+// 第一返回值：true能通过/false不能；第二返回值：当前Breaker状态的文字描述。
+func (b *tokenBucketBreaker) Allow() (bool, string) {
+	if pass, statusMsg, forced := forcedAllow(&b.forcedStatus); forced {
+		return pass, statusMsg // 手动强制状态优先于自动判断。
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.refill()
+	if b.tokens < 1 {
+		return false, fmt.Sprintf("tokens = %3.3f", b.tokens)
+	}
+	b.tokens--
+	return true, fmt.Sprintf("tokens = %3.3f", b.tokens)
+}
+
+// refill 用于根据距离上次补充的时间补充令牌，调用方需要持有锁。
+func (b *tokenBucketBreaker) refill() {
+	now := b.now()
+	if elapsed := now.Sub(b.lastRefill); elapsed > 0 {
+		b.tokens = math.Min(float64(b.burst), b.tokens+elapsed.Seconds()*b.rate)
+	}
+	b.lastRefill = now
+}
+
+// availableTokens 返回当前剩余的令牌数量，不消耗令牌。
+func (b *tokenBucketBreaker) availableTokens() float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.refill()
+	return b.tokens
+}
+
+// status 返回当前状态的文字描述，直接显示剩余令牌数量。
+func (b *tokenBucketBreaker) status() string {
+	if _, statusMsg, forced := forcedAllow(&b.forcedStatus); forced {
+		return statusMsg
+	}
+	return fmt.Sprintf("tokens = %3.3f", b.availableTokens())
+}
+
+// Success 用于记录成功事件。
+func (b *tokenBucketBreaker) Success() {
+	b.metric.Success()
+}
+
+// Failure 用于记录失败事件。
+func (b *tokenBucketBreaker) Failure() {
+	b.metric.Failure()
+}
+
+// Timeout 用于记录超时事件。
+func (b *tokenBucketBreaker) Timeout() {
+	b.metric.Timeout()
+}
+
+// Latency 记录一次功能函数执行耗时。
+func (b *tokenBucketBreaker) Latency(d time.Duration) {
+	b.metric.Latency(d)
+}
+
+// FallbackSuccess 记录一次降级函数执行成功事件。
+func (b *tokenBucketBreaker) FallbackSuccess() {
+	b.metric.FallbackSuccess()
+}
+
+// FallbackFailure 记录一次降级函数执行失败事件。
+func (b *tokenBucketBreaker) FallbackFailure() {
+	b.metric.FallbackFailure()
+}
+
+// Summary 返回当前健康状态。
+func (b *tokenBucketBreaker) Summary() *BreakerSummary {
+	return newBreakerSummary(b.name, b.status(), b.metric.Summary(), 1)
+}
+
+// Drain 返回当前健康状态，并同时重置上报用的统计数据。
+func (b *tokenBucketBreaker) Drain() *BreakerSummary {
+	return newBreakerSummary(b.name, b.status(), b.metric.Drain(), 1)
+}
+
+// LatencyBuckets 返回滑动窗口中按时间分布的耗时直方图。
+func (b *tokenBucketBreaker) LatencyBuckets() [][]int64 {
+	return b.metric.LatencyBuckets()
+}
+
+// State 返回熔断器当前状态，没有令牌时视为开启。
+func (b *tokenBucketBreaker) State() State {
+	if isForced(&b.forcedStatus) {
+		return StateForced
+	}
+	if b.availableTokens() < 1 {
+		return StateOpen
+	}
+	return StateClosed
+}
+
+// Reset 用于清空统计数据，并将令牌桶重新填满。
+func (b *tokenBucketBreaker) Reset() {
+	b.metric.Reset()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.tokens = float64(b.burst)
+	b.lastRefill = b.now()
+}
+
+// now 返回当前时间，没有设置时间源时使用系统时间。
+func (b *tokenBucketBreaker) now() time.Time {
+	if b.clock != nil {
+		return b.clock.Now()
+	}
+	return time.Now()
+}
+
+// Config 返回熔断器的有效配置。
+func (b *tokenBucketBreaker) Config() BreakerConfig {
+	return BreakerConfig{
+		Type: "tokenBucket",
+		Options: map[string]interface{}{
+			"timeWindow": b.timeWindow,
+			"rate":       b.rate,
+			"burst":      b.burst,
+		},
+	}
+}
+
+// ForceOpen 用于手动强制开启熔断器，强制期间拒绝所有请求。
+func (b *tokenBucketBreaker) ForceOpen() {
+	atomic.StoreInt32(&b.forcedStatus, forcedOpen)
+}
+
+// ForceClose 用于手动强制关闭熔断器，强制期间放行所有请求，且不消耗令牌。
+func (b *tokenBucketBreaker) ForceClose() {
+	atomic.StoreInt32(&b.forcedStatus, forcedClosed)
+}
+
+// ClearForced 用于清除手动强制状态，恢复熔断器的自动判断。
+func (b *tokenBucketBreaker) ClearForced() {
+	atomic.StoreInt32(&b.forcedStatus, forcedNone)
+}
+
+// TokenBucketBreakerOption 是 TokenBucketBreaker 的可选项。
+type TokenBucketBreakerOption func(b *tokenBucketBreaker)
+
+// WithTokenBucketBreakerRate 设置每秒补充的令牌数量，即稳定状态下每秒允许的请求数量（默认100）。
+func WithTokenBucketBreakerRate(r float64) TokenBucketBreakerOption {
+	return func(b *tokenBucketBreaker) {
+		b.rate = r
+	}
+}
+
+// WithTokenBucketBreakerBurst 设置令牌桶的容量，即空闲一段时间后允许的最大突发请求数量（默认100）。
+func WithTokenBucketBreakerBurst(burst int64) TokenBucketBreakerOption {
+	return func(b *tokenBucketBreaker) {
+		b.burst = burst
+	}
+}
+
+// WithTokenBucketBreakerTimeWindow 设置统计数据滑动窗口的大小（要求1-60s）。
+func WithTokenBucketBreakerTimeWindow(timeWindow time.Duration) TokenBucketBreakerOption {
+	return func(b *tokenBucketBreaker) {
+		b.timeWindow = timeWindow
+	}
+}
+
+// WithTokenBucketBreakerClock 设置补充令牌与统计数据使用的时间源（默认系统时间），用于在测试中驱动时间。
+func WithTokenBucketBreakerClock(clock Clock) TokenBucketBreakerOption {
+	return func(b *tokenBucketBreaker) {
+		b.clock = clock
+	}
+}
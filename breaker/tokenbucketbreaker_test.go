@@ -0,0 +1,63 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTokenBucketBreaker_rate 测试稳定状态下每秒放行的请求数量与设置的速率一致。
+func TestTokenBucketBreaker_rate(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := NewTokenBucketBreaker("test",
+		WithTokenBucketBreakerRate(50),
+		WithTokenBucketBreakerBurst(10),
+		WithTokenBucketBreakerClock(clock))
+
+	// allowed 统计每ms尝试10次请求，持续d时放行的数量。
+	allowed := func(d time.Duration) int {
+		count := 0
+		for elapsed := time.Duration(0); elapsed < d; elapsed += time.Millisecond {
+			for i := 0; i < 10; i++ {
+				if pass, _ := b.Allow(); pass {
+					count++
+				}
+				b.Success()
+			}
+			clock.Advance(time.Millisecond)
+		}
+		return count
+	}
+
+	// 初始时令牌桶是满的，允许突发burst个请求。
+	if n := allowed(time.Millisecond); n != 10 {
+		t.Errorf("allowed in burst got = %d, want %d", n, 10)
+	}
+	if state := b.State(); state != StateOpen {
+		t.Errorf("TokenBucketBreaker.State() got = %v, want %v", state, StateOpen)
+	}
+
+	// 稳定状态下每秒放行rate个请求。
+	for i := 0; i < 3; i++ {
+		if n := allowed(time.Second); n < 49 || n > 51 {
+			t.Errorf("allowed per second got = %d, want %d", n, 50)
+		}
+	}
+
+	// 记录的执行结果依然正常统计。
+	if summary := b.Summary(); summary.Success == 0 {
+		t.Errorf("TokenBucketBreaker.Summary() Success got = %d, want > 0", summary.Success)
+	}
+
+	// 空闲后令牌最多积累burst个。
+	clock.Advance(time.Minute)
+	if n := allowed(time.Millisecond); n != 10 {
+		t.Errorf("allowed after idle got = %d, want %d", n, 10)
+	}
+
+	// 手动强制状态优先。
+	b.ForceClose()
+	if pass, status := b.Allow(); !pass || status != "forced-closed" {
+		t.Errorf("TokenBucketBreaker.Allow() got = %v, %s, want %v, %s", pass, status, true, "forced-closed")
+	}
+}
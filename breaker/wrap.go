@@ -0,0 +1,40 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCircuitOpen 表示熔断器拒绝了请求，Wrap 包装的函数被拒绝时返回包装了该错误的错误。
+var ErrCircuitOpen error = errors.New("breaker: circuit open")
+
+// Wrap 用于将熔断器直接套在已有的函数上，适合不需要 Command 的超时、降级等功能的简单场景。
+// 执行前通过 Allow 判断是否放行，拒绝时不执行fn，返回包装了 ErrCircuitOpen 的错误；
+// 放行时执行fn，并按返回的错误记录耗时与成功/失败，fn panic时记录失败后继续panic。
+func Wrap(b Breaker, fn func() error) func() error {
+	return func() error {
+		if pass, statusMsg := b.Allow(); !pass {
+			return fmt.Errorf("%s: %w", statusMsg, ErrCircuitOpen)
+		}
+
+		startTime := time.Now()
+		finished := false
+		defer func() {
+			if !finished { // fn panic了，同样需要记录，部分熔断器依赖耗时释放并发数。
+				b.Latency(time.Since(startTime))
+				b.Failure()
+			}
+		}()
+		err := fn()
+		finished = true
+
+		b.Latency(time.Since(startTime))
+		if err != nil {
+			b.Failure()
+			return err
+		}
+		b.Success()
+		return nil
+	}
+}
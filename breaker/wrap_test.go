@@ -0,0 +1,77 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+	b := NewCutBreaker("test", WithCutBreakerTimeWindow(5*time.Second))
+	runErr := errors.New("must err")
+	calls := 0
+	fn := Wrap(b, func() error {
+		calls++
+		if calls == 2 {
+			return runErr
+		}
+		return nil
+	})
+
+	// 放行时执行函数，按返回的错误记录成功/失败。
+	if err := fn(); err != nil {
+		t.Errorf("Wrap() 1st call got = %v, want nil", err)
+	}
+	if err := fn(); err != runErr {
+		t.Errorf("Wrap() 2nd call got = %v, want %v", err, runErr)
+	}
+	summary := b.Summary()
+	if summary.Success != 1 || summary.Failure != 1 || calls != 2 {
+		t.Errorf("Wrap() recorded success/failure/calls got = %d/%d/%d, want %d/%d/%d", summary.Success, summary.Failure, calls, 1, 1, 2)
+	}
+	var latencies int64
+	for _, buckets := range b.LatencyBuckets() {
+		for _, count := range buckets {
+			latencies += count
+		}
+	}
+	if latencies != 2 {
+		t.Errorf("Wrap() recorded latencies got = %d, want %d", latencies, 2)
+	}
+
+	// 拒绝时不执行函数，也不记录结果。
+	b.ForceOpen()
+	if err := fn(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Wrap() rejected got = %v, want %v", err, ErrCircuitOpen)
+	}
+	summary = b.Summary()
+	if summary.Success != 1 || summary.Failure != 1 || calls != 2 {
+		t.Errorf("Wrap() rejected success/failure/calls got = %d/%d/%d, want %d/%d/%d", summary.Success, summary.Failure, calls, 1, 1, 2)
+	}
+}
+
+// TestWrap_panic 测试函数panic时记录失败并释放并发数后继续panic。
+func TestWrap_panic(t *testing.T) {
+	t.Parallel()
+	b := NewGradientBreaker("test", WithGradientBreakerInitialLimit(1))
+	fn := Wrap(b, func() error {
+		panic("boom")
+	})
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Wrap() panic got = %v, want %v", r, "boom")
+			}
+		}()
+		fn()
+	}()
+
+	if summary := b.Summary(); summary.Failure != 1 {
+		t.Errorf("Wrap() panic Failure got = %d, want %d", summary.Failure, 1)
+	}
+	if pass, _ := b.Allow(); !pass { // 并发数已经释放。
+		t.Errorf("GradientBreaker.Allow() after panic got = %v, want %v", pass, true)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bunnier/circuit/breaker"
@@ -19,40 +21,167 @@ type CommandFunc func(context.Context, interface{}) (interface{}, error)
 // CommandFallbackFunc 是降级函数签名。
 //   context.Context 执行时将通过command的默认超时时间新建一个context，不会复用功能函数的，以免累计超时时间。
 //   interface{} 为传递给功能函数的interface{}参数。
-//   error 为功能返回值的error。
+//   error 为功能返回值的error；没有执行功能函数时为拒绝原因，熔断器开启时可通过errors.Is(err, ErrCircuitOpen)判断，
+//   并发数已满、挂起的执行过多时分别为ErrMaxConcurrency、ErrStuck，以便区分“被熔断器拒绝”与“功能函数执行失败”。
 type CommandFallbackFunc func(context.Context, interface{}, error) (interface{}, error) // 降级函数签名。
 
-var ErrTimeout error = errors.New("command: timeout")         // 服务执行超时。
-var ErrUnavailable error = errors.New("command: unavailable") // 服务不可用（熔断器开启后返回）。
+// CommandConfirmFunc 是确认函数签名，用于在功能函数超时后确认操作实际是否已经完成。
+//   context.Context 执行时将通过command的默认超时时间新建一个context，不会复用功能函数的。
+//   interface{} 为传递给功能函数的interface{}参数。
+//   返回值bool为true时表示操作已经完成。
+type CommandConfirmFunc func(context.Context, interface{}) (bool, error)
+
+var ErrTimeout error = errors.New("command: timeout")                // 服务执行超时。
+var ErrUnavailable error = errors.New("command: unavailable")        // 服务不可用。
+var ErrMaxConcurrency error = errors.New("command: max concurrency") // 并发执行数量已满。
+var ErrStuck error = errors.New("command: too many stuck")           // 超时后仍未返回的执行数量过多。
+var ErrPanic error = errors.New("command: panic")                    // 功能函数或降级函数panic，需要设置 WithCommandRecoverPanic。
+var ErrClosed error = errors.New("command: closed")                  // Command已经释放资源，不能再执行。
+var ErrInvalidConfig error = errors.New("command: invalid config")   // Command的配置错误，由 NewCommandE 返回。
+var ErrResultFailure error = errors.New("command: result failure")   // 功能函数没有返回错误，但结果被判断为失败，需要设置 WithCommandSuccessPredicate。
+
+// ErrCircuitOpen 表示熔断器开启，拒绝执行。
+// 该错误包装了ErrUnavailable，原有通过errors.Is(err, ErrUnavailable)判断熔断的代码依然有效。
+var ErrCircuitOpen error = &sentinelError{"command: circuit open", ErrUnavailable}
+
+// sentinelError 用于定义包装了其它错误的哨兵错误。
+type sentinelError struct {
+	msg    string
+	parent error
+}
+
+func (e *sentinelError) Error() string {
+	return e.msg
+}
+
+func (e *sentinelError) Unwrap() error {
+	return e.parent
+}
+
+// TimeoutStage 表示超时发生的执行阶段。
+type TimeoutStage string
+
+const (
+	StageRun      TimeoutStage = "run"      // 功能函数超过了Command的超时时间。
+	StageFallback TimeoutStage = "fallback" // 降级函数超过了Command的超时时间。
+	StageOverall  TimeoutStage = "overall"  // 调用方通过context传入的整体超时时间先耗尽。
+)
+
+// TimeoutError 是执行超时时返回的错误，可通过errors.As获取超时发生的阶段。
+// 该错误包装了ErrTimeout，依然可以通过errors.Is(err, ErrTimeout)判断。
+type TimeoutError struct {
+	Name  string       // Command名称。
+	Stage TimeoutStage // 超时发生的阶段。
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Name, e.Stage, ErrTimeout)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return ErrTimeout
+}
+
+// PanicError 是设置了 WithCommandRecoverPanic 后，功能函数或降级函数panic时返回的错误，可通过errors.As获取panic的值。
+// 该错误包装了ErrPanic，可以通过errors.Is(err, ErrPanic)判断。
+type PanicError struct {
+	Name  string      // Command名称。
+	Value interface{} // panic的值。
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.Name, ErrPanic, e.Value)
+}
+
+func (e *PanicError) Unwrap() error {
+	return ErrPanic
+}
 
 // 在断路器中执行的命令对象。
 type Command struct {
-	cancel context.CancelFunc // 用于释放内部的goroutine。
+	cancel    context.CancelFunc // 用于释放内部的goroutine。
+	done      <-chan struct{}    // 释放资源后关闭的channel。
+	closeOnce sync.Once          // 保证只释放一次资源。
+
+	draining    int32         // 是否已经停止接收新的执行，需要原子操作。
+	inFlight    int64         // 正在执行的数量，需要原子操作。
+	drained     chan struct{} // 停止接收新的执行且正在执行的都已完成后关闭。
+	drainedOnce sync.Once     // 保证只关闭一次drained。
 
 	name string // 名称。
 
 	run      CommandFunc         // 功能函数。
 	fallback CommandFallbackFunc // 降级函数。
 
-	timeout *time.Duration // 超时时间。
+	lastResort func(param interface{}, runErr, fallbackErr error) (interface{}, error) // 功能函数与降级函数都失败时的最后处理函数（可选）。
+
+	timeout         *time.Duration // 超时时间。
+	fallbackTimeout *time.Duration // 降级函数的超时时间（可选），没有设置时与功能函数相同。
+
+	retryMaxAttempts int                             // 功能函数最多执行的次数（含第一次），小于等于1时不重试。
+	retryBackoff     func(attempt int) time.Duration // 第attempt次执行失败后，到下一次重试前的等待时间。
+	idempotent       bool                            // 功能函数是否幂等，不幂等时不会重试。
+
+	recoverPanic bool // 是否将功能函数与降级函数的panic转换为错误返回，而不是继续panic。
+
+	semaphore chan struct{} // 用于限制最大并发执行数量的信号量（可选）。
+
+	stuckInFlight    int64 // 超时后仍未返回的执行数量。
+	maxStuckInFlight int64 // 允许超时后仍未返回的最大执行数量，为0时不限制。
+
+	breaker       breaker.Breaker // 熔断器。
+	stateNotified bool            // 熔断器实现了 breaker.StateNotifier，状态变化由熔断器通知，不需要在执行前后比较状态。
+	watchOnce     sync.Once       // 保证只向熔断器注册一次状态变化监听函数。
+
+	errorKeys     *errorKeyCounter // 错误特征计数器（可选）。
+	failureFilter func(error) bool // 判断错误是否计为失败的函数（可选）。
+
+	successPredicate func(result interface{}, err error) bool // 判断执行结果是否成功的函数（可选）。
+
+	confirm CommandConfirmFunc // 超时后确认操作是否已经完成的函数（可选）。
+
+	contextEnricher func(context.Context) context.Context // 用于为每次执行的context附加统一的值（可选）。
+
+	eventCh         chan<- Event // 用于发布执行事件的channel（可选）。
+	eventDropIfFull bool         // channel已满时是否丢弃事件。
+	events          atomic.Value // 通过 Events 订阅事件的channel，类型为 chan Event，没有订阅时为空。
+	eventsOnce      sync.Once    // 保证只创建一次订阅的channel。
 
-	breaker breaker.Breaker // 熔断器。
+	logger Logger // 用于输出熔断开启、拒绝请求、降级等事件的日志（可选），成功的执行不输出日志。
 }
 
+// NewCommand 用于新建一个Command，name为空时将生成一个“command-序号”形式的名称，可以通过 Command.Name 获取。
+// 熔断器等配置错误时将直接panic，需要返回错误时请使用 NewCommandE。
 func NewCommand(name string, run CommandFunc, options ...CommandOptionFunc) *Command {
 	ctx, cancel := context.WithCancel(context.Background()) // 这个context主要用于处理内部的资源释放，而非执行功能函数。
 
+	if name == "" { // 名称会出现在错误信息与统计数据中，不能为空，没有指定时生成一个。
+		name = fmt.Sprintf("command-%d", atomic.AddInt64(&commandSeq, 1))
+	}
+
 	command := &Command{
-		cancel: cancel,
-		name:   name,
-		run:    run,
+		cancel:     cancel,
+		done:       ctx.Done(),
+		drained:    make(chan struct{}),
+		name:       name,
+		run:        run,
+		idempotent: true, // 默认视为幂等，与之前的行为保持一致。
 	}
 
+	// 先应用全局默认选项，再应用传入的选项，传入的选项优先。
+	for _, option := range getDefaultOptions() {
+		option(command)
+	}
 	for _, option := range options {
 		option(command)
 	}
 
 	// breaker对象比较大，就不在前面设置默认值了。
+	if command.breaker == nil {
+		if factory := getDefaultBreakerFactory(); factory != nil {
+			command.breaker = factory(name)
+		}
+	}
 	if command.breaker == nil {
 		command.breaker = breaker.NewCutBreaker(name,
 			breaker.WithCutBreakerContext(ctx),
@@ -62,19 +191,56 @@ func NewCommand(name string, run CommandFunc, options ...CommandOptionFunc) *Com
 			breaker.WithCutBreakerSleepWindow(5*time.Second))
 	}
 
-	if command.timeout != nil {
-		command.run = wrapCommandFuncWithTimeout(command, command.run)
-
-		// 如果有降级函数，也打包一层超时处理。
-		// 执行时将通过command的默认超时时间新建一个context，不会复用功能函数的，以免累计超时时间。
-		if command.fallback != nil {
-			command.fallback = wrapCommandFallbackFuncWithTimeout(command, command.fallback)
+	// 熔断器可以通知状态变化时，由熔断器在状态切换时通知，并发执行时也不会重复或遗漏。
+	if _, ok := command.breaker.(breaker.StateNotifier); ok {
+		command.stateNotified = true
+		if command.logger != nil {
+			command.watchState()
 		}
 	}
 
 	return command
 }
 
+// NewCommandE 与 NewCommand 相同，但配置错误时返回包装了 ErrInvalidConfig 的错误而不是panic，适合在服务中根据配置创建Command。
+// 校验比 NewCommand 更严格：name不能为空，run不能为nil，设置了超时时间（包括降级函数的）时必须大于0；
+// 应用选项与创建熔断器时的panic（如熔断器的窗口大小错误）也将转换为错误返回。
+func NewCommandE(name string, run CommandFunc, options ...CommandOptionFunc) (command *Command, err error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: empty name", ErrInvalidConfig)
+	}
+	if run == nil {
+		return nil, fmt.Errorf("%s: %w: nil run function", name, ErrInvalidConfig)
+	}
+
+	defer func() {
+		if panicObj := recover(); panicObj != nil {
+			command, err = nil, fmt.Errorf("%s: %w: %v", name, ErrInvalidConfig, panicObj)
+		}
+	}()
+	command = NewCommand(name, run, options...)
+
+	switch {
+	case command.timeout != nil && *command.timeout <= 0:
+		err = fmt.Errorf("%s: %w: timeout must be greater than 0", name, ErrInvalidConfig)
+	case command.fallbackTimeout != nil && *command.fallbackTimeout <= 0:
+		err = fmt.Errorf("%s: %w: fallback timeout must be greater than 0", name, ErrInvalidConfig)
+	}
+	if err != nil {
+		command.Close()
+		return nil, err
+	}
+	return command, nil
+}
+
+// commandSeq 用于为没有指定名称的Command生成名称。
+var commandSeq int64
+
+// Name 返回Command的名称，创建时没有指定名称的，返回生成的名称。
+func (command *Command) Name() string {
+	return command.name
+}
+
 // Execute 用于直接执行目标函数。
 func (command *Command) Execute(param interface{}) (interface{}, error) {
 	return command.ContextExecute(context.Background(), param)
@@ -82,59 +248,278 @@ func (command *Command) Execute(param interface{}) (interface{}, error) {
 
 // Execute 用于直接执行目标函数。
 func (command *Command) ContextExecute(ctx context.Context, param interface{}) (interface{}, error) {
+	return command.contextExecute(ctx, param, ExecOptions{}, nil)
+}
+
+// ExecuteWithTimeout 用于以指定的超时时间执行目标函数，仅对本次执行生效，熔断与降级逻辑不变。
+func (command *Command) ExecuteWithTimeout(param interface{}, timeout time.Duration) (interface{}, error) {
+	return command.contextExecute(context.Background(), param, ExecOptions{Timeout: timeout}, nil)
+}
+
+// ContextExecuteWithBudget 用于执行目标函数，并返回本次执行剩余未使用的超时预算，便于串联多个Command时将剩余预算传递下去。
+// 超时预算取Command的超时时间与ctx截止时间中较早的一个；没有任何超时限制或预算已耗尽时，剩余预算均返回0。
+func (command *Command) ContextExecuteWithBudget(ctx context.Context, param interface{}) (interface{}, time.Duration, error) {
+	var deadline time.Time
+	if timeout := command.getTimeout(ExecOptions{}); timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if ctxDeadline, ok := ctx.Deadline(); ok && (deadline.IsZero() || ctxDeadline.Before(deadline)) {
+		deadline = ctxDeadline
+	}
+
+	result, err := command.ContextExecute(ctx, param)
+
+	var remaining time.Duration
+	if !deadline.IsZero() {
+		if remaining = time.Until(deadline); remaining < 0 {
+			remaining = 0
+		}
+	}
+	return result, remaining, err
+}
+
+// contextExecute 用于按单次执行的可选项执行目标函数。
+// info 不为nil时，记录本次执行经过的分支。
+func (command *Command) contextExecute(ctx context.Context, param interface{}, opts ExecOptions, info *ExecInfo) (interface{}, error) {
+	if command.Closed() { // 已经释放资源，不再执行，也不再记录统计数据。
+		return nil, fmt.Errorf("%s: %w", command.name, ErrClosed)
+	}
+
+	// 先计入正在执行的数量再检查，与 Drain 的顺序相反，保证 Wait 不会漏掉已经开始的执行。
+	atomic.AddInt64(&command.inFlight, 1)
+	defer command.release()
+	if atomic.LoadInt32(&command.draining) == 1 { // 已经停止接收新的执行。
+		return nil, fmt.Errorf("%s: draining: %w", command.name, ErrClosed)
+	}
+
+	ctx = command.enrichContext(ctx)
+	before := command.stateForLog()
 	pass, statusMsg := command.breaker.Allow()
+	command.logStateChange(before, pass)
+
+	// 本次执行禁用降级函数时，按没有设置降级函数处理。
+	hasFallback := command.fallback != nil && !opts.DisableFallback
+	timeout := command.getTimeout(opts)
 
 	// 已经熔断直接走降级逻辑。
 	if !pass {
-		openErr := fmt.Errorf("%s: %s: %w", command.name, statusMsg, ErrUnavailable)
-		if command.fallback == nil { // 没有设置降级函数直接返回
+		openErr := fmt.Errorf("%s: %s: %w", command.name, statusMsg, ErrCircuitOpen)
+		command.emit(EventRejected, openErr)
+		command.log("request rejected", openErr)
+		info.markRejected()
+		if !hasFallback { // 没有设置降级函数直接返回
 			return nil, openErr
 		}
-		return command.contextExecuteFallback(param, openErr) // 降级函数。
+		return command.contextExecuteFallback(param, openErr, timeout, info) // 降级函数。
 	}
 
-	if result, err := command.run(ctx, param); err != nil {
-		if panicErr, ok := err.(funcPanicError); ok { // 如果是panic错误，统计后依然panic掉。
+	// 设置了最大并发数时，先获取信号量，获取不到按失败处理，直接走降级逻辑。
+	if command.semaphore != nil {
+		select {
+		case command.semaphore <- struct{}{}:
+			defer func() { <-command.semaphore }() // 通过defer释放，即使panic也能释放。
+		default:
 			command.breaker.Failure()
-			panic(panicErr.panicObj)
+			command.releaseBreaker()
+			concurrencyErr := fmt.Errorf("%s: %w", command.name, ErrMaxConcurrency)
+			command.emit(EventRejected, concurrencyErr)
+			command.log("request rejected", concurrencyErr)
+			info.markRejected()
+			if !hasFallback { // 没有设置降级函数直接返回
+				return nil, concurrencyErr
+			}
+			return command.contextExecuteFallback(param, concurrencyErr, timeout, info) // 降级函数。
 		}
+	}
 
+	// 超时后仍未返回的执行过多，说明下游可能已经挂起，按失败处理，直接走降级逻辑。
+	if command.maxStuckInFlight > 0 && atomic.LoadInt64(&command.stuckInFlight) > command.maxStuckInFlight {
+		command.breaker.Failure()
+		command.releaseBreaker()
+		stuckErr := fmt.Errorf("%s: %w", command.name, ErrStuck)
+		command.emit(EventRejected, stuckErr)
+		command.log("request rejected", stuckErr)
+		info.markRejected()
+		if !hasFallback { // 没有设置降级函数直接返回
+			return nil, stuckErr
+		}
+		return command.contextExecuteFallback(param, stuckErr, timeout, info) // 降级函数。
+	}
+
+	run := command.run
+	if command.successPredicate != nil { // 包装在重试之内，结果被判断为失败时同样会重试。
+		run = wrapCommandFuncWithSuccessPredicate(command, run)
+	}
+	if command.retryMaxAttempts > 1 && command.idempotent { // 不幂等的操作重试可能导致重复写入。
+		run = wrapCommandFuncWithRetry(command, run)
+	}
+	if command.recoverPanic { // 包装在重试之外，panic后不再重试。
+		run = wrapCommandFuncWithRecover(run)
+	}
+	if timeout > 0 { // 超时包装在重试之外，所有重试共享同一个超时时间，耗时也在其中记录。
+		run = wrapCommandFuncWithTimeout(command, run, timeout)
+	} else {
+		run = wrapCommandFuncWithLatency(command, run)
+	}
+
+	startTime := time.Now()
+	if result, err := run(ctx, param); err != nil {
+		if panicErr, ok := err.(funcPanicError); ok {
+			command.log("panic in run function", panicErr)
+			if !command.recoverPanic { // 没有设置恢复时，统计后依然panic掉。
+				command.breaker.Failure()
+				command.emit(EventFailure, panicErr)
+				panic(panicErr.panicObj)
+			}
+			err = &PanicError{command.name, panicErr.panicObj} // 按普通的失败处理。
+		}
+
+		// 功能函数自行指定了执行结果。
+		var outcomeErr *outcomeError
+		if errors.As(err, &outcomeErr) {
+			if outcomeErr.outcome == outcomeDegraded {
+				command.breaker.Failure()
+				command.emit(EventFailure, err)
+				if command.errorKeys != nil {
+					command.errorKeys.record(err)
+				}
+			} else {
+				command.emit(EventIgnored, err)
+			}
+			return result, err
+		}
+
+		// 不计为失败的错误，熔断器记为成功，错误原样返回给调用方。
+		if command.failureFilter != nil && !command.failureFilter(err) {
+			command.recordSuccess(time.Since(startTime))
+			command.emit(EventSuccess, err)
+			return result, err
+		}
+
+		before = command.stateForLog()
 		if errors.Is(err, ErrTimeout) {
 			command.breaker.Timeout()
+			command.emit(EventTimeout, err)
+			info.markTimedOut()
+			command.logStateChange(before, false)
+			// 超时的操作可能在服务端已经完成，确认完成后不再执行降级函数，以免重复写入。
+			if command.confirm != nil && command.confirmDone(param, timeout) {
+				return nil, nil
+			}
 		} else {
 			command.breaker.Failure()
+			command.emit(EventFailure, err)
+			command.logStateChange(before, false)
+		}
+
+		if command.errorKeys != nil {
+			command.errorKeys.record(err)
 		}
 
-		if command.fallback == nil { // 没有设置降级函数直接返回
+		if !hasFallback { // 没有设置降级函数直接返回
 			return nil, err
 		}
-		return command.contextExecuteFallback(result, err) // 降级函数。
+		return command.contextExecuteFallback(param, err, timeout, info) // 降级函数，传入的是功能函数的参数。
 	} else {
-		command.breaker.Success()
+		before = command.stateForLog()
+		command.recordSuccess(time.Since(startTime))
+		command.emit(EventSuccess, nil)
+		command.logStateChange(before, true) // 半开状态下的成功可能关闭熔断器。
 		return result, nil
 	}
 }
 
+// recordSuccess 用于记录一次成功，熔断器需要成功执行的耗时（如 CutBreaker 统计慢调用）时一并传入。
+func (command *Command) recordSuccess(elapsed time.Duration) {
+	if recorder, ok := command.breaker.(breaker.SuccessLatencyRecorder); ok {
+		recorder.SuccessLatency(elapsed)
+	}
+	command.breaker.Success()
+}
+
+// releaseBreaker 用于在熔断器放行后没有执行功能函数时，归还放行时占用的资源（如 GradientBreaker 的并发数）。
+func (command *Command) releaseBreaker() {
+	if releaser, ok := command.breaker.(breaker.Releaser); ok {
+		releaser.Release()
+	}
+}
+
 // contextExecuteFallback 用于执行降级函数。
-func (command *Command) contextExecuteFallback(param interface{}, err error) (interface{}, error) {
-	ctx := context.Background()
-	if command.timeout != nil {
-		ctxWt, cancel := context.WithTimeout(ctx, *command.timeout)
-		ctx = ctxWt
-		defer cancel()
+// 执行时将通过超时时间新建一个context，不会复用功能函数的，以免累计超时时间。
+func (command *Command) contextExecuteFallback(param interface{}, runErr error, timeout time.Duration, info *ExecInfo) (interface{}, error) {
+	info.markFallback()
+	command.log("fallback invoked", runErr)
+	ctx := command.enrichContext(context.Background())
+	timeout = command.getFallbackTimeout(timeout)
+	fallback := command.fallback
+	if command.recoverPanic {
+		fallback = wrapCommandFallbackFuncWithRecover(fallback)
 	}
-	res, err := command.fallback(ctx, param, err)
+	if timeout > 0 { // 有超时时间时，也打包一层超时处理。
+		fallback = wrapCommandFallbackFuncWithTimeout(command, fallback, timeout)
+	}
+	res, err := fallback(ctx, param, runErr)
 	if err != nil {
 		command.breaker.FallbackFailure()
-		if panicErr, ok := err.(funcPanicError); ok { // 如果是panic错误，统计后依然panic掉。
-			panic(panicErr.panicObj)
+		command.emit(EventFallbackFailure, err)
+		if panicErr, ok := err.(funcPanicError); ok {
+			command.log("panic in fallback function", panicErr)
+			if !command.recoverPanic { // 没有设置恢复时，统计后依然panic掉。
+				panic(panicErr.panicObj)
+			}
+			err = &PanicError{command.name, panicErr.panicObj}
+		}
+		if command.lastResort != nil { // 都失败了，交给最后处理函数。
+			return command.lastResort(param, runErr, err)
 		}
 		return res, err
 	}
 	command.breaker.FallbackSuccess()
+	command.emit(EventFallbackSuccess, nil)
 	return res, err
 }
 
+// confirmDone 用于在功能函数超时后，通过确认函数判断操作是否已经完成，确认函数返回错误时按未完成处理。
+// 执行时将通过超时时间新建一个context，不会复用功能函数的。
+func (command *Command) confirmDone(param interface{}, timeout time.Duration) bool {
+	ctx := command.enrichContext(context.Background())
+	if timeout > 0 {
+		ctxWt, cancel := context.WithTimeout(ctx, timeout)
+		ctx = ctxWt
+		defer cancel()
+	}
+	done, err := command.confirm(ctx, param)
+	return err == nil && done
+}
+
+// enrichContext 用于为context附加Command名称，再通过设置的函数附加统一的值（如有）。
+func (command *Command) enrichContext(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, commandNameKey{}, command.name)
+	if command.contextEnricher == nil {
+		return ctx
+	}
+	return command.contextEnricher(ctx)
+}
+
+// getTimeout 返回本次执行的超时时间，单次执行的可选项优先，为0时表示不限制。
+func (command *Command) getTimeout(opts ExecOptions) time.Duration {
+	if opts.Timeout > 0 {
+		return opts.Timeout
+	}
+	if command.timeout != nil {
+		return *command.timeout
+	}
+	return 0
+}
+
+// getFallbackTimeout 返回降级函数的超时时间，没有单独设置时使用本次执行功能函数的超时时间。
+func (command *Command) getFallbackTimeout(runTimeout time.Duration) time.Duration {
+	if command.fallbackTimeout != nil {
+		return *command.fallbackTimeout
+	}
+	return runTimeout
+}
+
 // funcResType 将功能函数/降级函数的返回值打包成一个结构。
 type funcResType struct {
 	res interface{}
@@ -148,29 +533,47 @@ type funcPanicError struct {
 }
 
 // wrapCommandFuncWithTimeout 用于对功能函数包装超时处理。
-func wrapCommandFuncWithTimeout(command *Command, run CommandFunc) CommandFunc {
+// 功能函数在独立的goroutine中执行，超时（或调用方取消）时立即返回，传入功能函数的context也会同时被取消；
+// 但goroutine本身无法被强制结束，如果功能函数不响应context的取消，将继续在后台执行直至完成，请务必在功能函数中处理ctx.Done()。
+func wrapCommandFuncWithTimeout(command *Command, run CommandFunc, timeout time.Duration) CommandFunc {
 	return func(ctx context.Context, param interface{}) (interface{}, error) {
 		resCh := make(chan funcResType, 1)   // 设置一个1的缓冲，以免超时后goroutine泄漏。
 		panicCh := make(chan interface{}, 1) // 由于放到独立的goroutine中，原本的panic保护会失效，这里做个panic转发，让其回归到原本的goroutine中。
 
-		ctx, cancel := context.WithTimeout(ctx, *command.timeout) // 为context加上统一的超时时间。
+		parentCtx := ctx
+		ctx, cancel := context.WithTimeout(ctx, timeout) // 为context加上超时时间。
 		defer cancel()
 
+		var state int32 // 执行状态，用于统计超时后仍未返回的执行数量。
 		go func() {
 			defer func() {
+				if !atomic.CompareAndSwapInt32(&state, running, finished) { // 已经被记为挂起，返回后需要减掉。
+					atomic.AddInt64(&command.stuckInFlight, -1)
+				}
 				if err := recover(); err != nil {
 					panicCh <- err
 				}
 			}()
 
-			res, err := run(ctx, param)
+			startTime := time.Now()
+			res, err := func() (interface{}, error) {
+				// 在goroutine中记录，超时后依然能统计到实际耗时；通过defer记录，panic时也能释放熔断器的并发数等资源。
+				defer func() { command.breaker.Latency(time.Since(startTime)) }()
+				return run(ctx, param)
+			}()
 			resCh <- funcResType{res, err}
 		}()
 
 		select {
 		case <-ctx.Done():
+			if atomic.CompareAndSwapInt32(&state, running, stuck) { // 功能函数还没有返回，记为挂起。
+				atomic.AddInt64(&command.stuckInFlight, 1)
+			}
 			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-				return nil, fmt.Errorf("%s: %w", command.name, ErrTimeout)
+				if parentCtx.Err() != nil { // 调用方传入的超时时间先到。
+					return nil, &TimeoutError{command.name, StageOverall}
+				}
+				return nil, &TimeoutError{command.name, StageRun}
 			}
 			return nil, fmt.Errorf("%s: %w", command.name, ctx.Err())
 		case panicObj := <-panicCh:
@@ -181,12 +584,91 @@ func wrapCommandFuncWithTimeout(command *Command, run CommandFunc) CommandFunc {
 	}
 }
 
-// wrapCommandFallbackFuncWithTimeout 用于对功能函数包装超时处理。
-func wrapCommandFallbackFuncWithTimeout(command *Command, run CommandFallbackFunc) CommandFallbackFunc {
+// 定义功能函数在超时包装中的执行状态常量。
+const (
+	running  int32 = 0 // 执行中。
+	finished int32 = 1 // 已返回。
+	stuck    int32 = 2 // 超时（或被取消）后仍未返回。
+)
+
+// wrapCommandFuncWithLatency 用于对功能函数包装耗时统计，用于没有设置超时时间的情况。
+func wrapCommandFuncWithLatency(command *Command, run CommandFunc) CommandFunc {
+	return func(ctx context.Context, param interface{}) (interface{}, error) {
+		startTime := time.Now()
+		defer func() { command.breaker.Latency(time.Since(startTime)) }()
+		return run(ctx, param)
+	}
+}
+
+// wrapCommandFuncWithRetry 用于对功能函数包装重试处理。
+// 执行失败后按退避时间等待并重试，直到成功或达到最大次数，只返回最后一次的结果；ctx结束时提前放弃重试。
+func wrapCommandFuncWithRetry(command *Command, run CommandFunc) CommandFunc {
+	return func(ctx context.Context, param interface{}) (interface{}, error) {
+		for attempt := 1; ; attempt++ {
+			res, err := run(ctx, param)
+			if err == nil || attempt >= command.retryMaxAttempts {
+				return res, err
+			}
+
+			var backoff time.Duration
+			if command.retryBackoff != nil {
+				backoff = command.retryBackoff(attempt)
+			}
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return res, err
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// wrapCommandFuncWithSuccessPredicate 用于对功能函数包装结果判断，没有返回错误但结果被判断为失败时，返回包装了 ErrResultFailure 的错误。
+func wrapCommandFuncWithSuccessPredicate(command *Command, run CommandFunc) CommandFunc {
+	return func(ctx context.Context, param interface{}) (interface{}, error) {
+		res, err := run(ctx, param)
+		if !command.successPredicate(res, err) && err == nil { // 返回了错误的依然按原来的错误处理。
+			return res, fmt.Errorf("%s: %w", command.name, ErrResultFailure)
+		}
+		return res, err
+	}
+}
+
+// wrapCommandFuncWithRecover 用于对功能函数包装panic恢复，将panic转换为 funcPanicError 返回。
+func wrapCommandFuncWithRecover(run CommandFunc) CommandFunc {
+	return func(ctx context.Context, param interface{}) (res interface{}, err error) {
+		defer func() {
+			if panicObj := recover(); panicObj != nil {
+				res, err = nil, funcPanicError{errors.New("panic"), panicObj}
+			}
+		}()
+		return run(ctx, param)
+	}
+}
+
+// wrapCommandFallbackFuncWithRecover 用于对降级函数包装panic恢复，将panic转换为 funcPanicError 返回。
+func wrapCommandFallbackFuncWithRecover(fallback CommandFallbackFunc) CommandFallbackFunc {
+	return func(ctx context.Context, param interface{}, runErr error) (res interface{}, err error) {
+		defer func() {
+			if panicObj := recover(); panicObj != nil {
+				res, err = nil, funcPanicError{errors.New("panic"), panicObj}
+			}
+		}()
+		return fallback(ctx, param, runErr)
+	}
+}
+
+// wrapCommandFallbackFuncWithTimeout 用于对降级函数包装超时处理，超时时间在包装内部设置，调用方传入不带超时的context也能生效。
+func wrapCommandFallbackFuncWithTimeout(command *Command, run CommandFallbackFunc, timeout time.Duration) CommandFallbackFunc {
 	return func(ctx context.Context, param interface{}, err error) (interface{}, error) {
 		resCh := make(chan funcResType, 1)   // 设置一个1的缓冲，以免超时后goroutine泄漏。
 		panicCh := make(chan interface{}, 1) // 由于放到独立的goroutine中，原本的panic保护会失效，这里做个panic转发，让其回归到原本的goroutine中。
 
+		ctx, cancel := context.WithTimeout(ctx, timeout) // 为context加上超时时间，不依赖调用方传入的context。
+		defer cancel()
+
 		go func() {
 			defer func() {
 				if err := recover(); err != nil {
@@ -201,7 +683,7 @@ func wrapCommandFallbackFuncWithTimeout(command *Command, run CommandFallbackFun
 		select {
 		case <-ctx.Done():
 			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-				return nil, fmt.Errorf("%s: %w", command.name, ErrTimeout)
+				return nil, &TimeoutError{command.name, StageFallback}
 			}
 			return nil, fmt.Errorf("%s: %w", command.name, ctx.Err())
 		case panicObj := <-panicCh:
@@ -212,9 +694,110 @@ func wrapCommandFallbackFuncWithTimeout(command *Command, run CommandFallbackFun
 	}
 }
 
-// Close 用于释放整个Command对象内部资源（）。
-func (command *Command) Close() {
-	command.cancel()
+// Summary 返回当前熔断器状态信息。
+func (command *Command) Summary() *breaker.BreakerSummary {
+	summary := command.breaker.Summary()
+	summary.Name = command.name // 熔断器可能是单独设置的，名称以Command为准。
+	if command.errorKeys != nil {
+		summary.TopErrorKeys = command.errorKeys.top(topErrorKeyCount, false)
+	}
+	return summary
+}
+
+// MetricsView 是Command统计数据的只读视图，可以长期持有并反复读取。
+type MetricsView interface {
+	Summary() *breaker.BreakerSummary // 返回读取时的最新状态信息。
+}
+
+// Metrics 返回Command统计数据的只读视图，便于监控面板持有后定期轮询。
+// 统计数据由原子计数器维护，每次读取的开销很小。
+func (command *Command) Metrics() MetricsView {
+	return metricsView{command}
+}
+
+// metricsView 是 MetricsView 的实现，只暴露读取方法。
+type metricsView struct {
+	command *Command
+}
+
+func (view metricsView) Summary() *breaker.BreakerSummary {
+	return view.command.Summary()
+}
+
+// DrainStats 返回当前熔断器状态信息与上一次DrainStats以来的统计数据，并同时重置这部分统计数据。
+// 用于按周期推送增量统计数据的场景，每次返回的统计数据互不重叠；熔断器的判断使用单独的滑动窗口，不受推送周期影响。
+func (command *Command) DrainStats() *breaker.BreakerSummary {
+	summary := command.breaker.Drain()
+	summary.Name = command.name // 熔断器可能是单独设置的，名称以Command为准。
+	if command.errorKeys != nil {
+		summary.TopErrorKeys = command.errorKeys.top(topErrorKeyCount, true)
+	}
+	return summary
+}
+
+// LatencyBuckets 返回功能函数按时间分布的耗时直方图，用于绘制热力图。
+// 第一维为统计区间（从旧到新），第二维为各个耗时桶的次数，桶的上限见 breaker.LatencyBucketBounds。
+func (command *Command) LatencyBuckets() [][]int64 {
+	return command.breaker.LatencyBuckets()
+}
+
+// Reset 用于立即重置熔断器，如下游修复上线后不必等待休眠时间结束。
+func (command *Command) Reset() {
+	command.breaker.Reset()
+}
+
+// Close 用于释放整个Command对象内部资源，实现了 io.Closer，可以重复调用，只有第一次生效，总是返回nil。
+// 释放后再执行将直接返回包装了 ErrClosed 的错误。
+func (command *Command) Close() error {
+	command.closeOnce.Do(command.cancel)
+	return nil
+}
+
+// Drain 用于优雅退出：停止接收新的执行，新的执行将直接返回包装了 ErrClosed 的错误，正在执行的不受影响。
+// 可以重复调用；之后可通过 Wait 等待正在执行的完成，再调用 Close 释放资源。
+func (command *Command) Drain() {
+	atomic.StoreInt32(&command.draining, 1)
+	if atomic.LoadInt64(&command.inFlight) == 0 {
+		command.drainedOnce.Do(func() { close(command.drained) })
+	}
+}
+
+// Wait 用于等待 Drain 之后正在执行的都完成，ctx结束时提前返回ctx.Err()。
+// 没有调用 Drain 时将一直等待到调用 Drain 且正在执行的都完成。
+func (command *Command) Wait(ctx context.Context) error {
+	select {
+	case <-command.drained: // 已经完成时优先返回nil，即使ctx也已经结束。
+		return nil
+	default:
+	}
+	select {
+	case <-command.drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release 用于在一次执行结束时减少正在执行的数量，Drain 之后减到0时通知 Wait。
+func (command *Command) release() {
+	if atomic.AddInt64(&command.inFlight, -1) == 0 && atomic.LoadInt32(&command.draining) == 1 {
+		command.drainedOnce.Do(func() { close(command.drained) })
+	}
+}
+
+// Closed 返回Command是否已经释放资源。
+func (command *Command) Closed() bool {
+	select {
+	case <-command.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Done 返回一个在Command释放资源（调用 Close）后关闭的channel，便于协调生命周期。
+func (command *Command) Done() <-chan struct{} {
+	return command.done
 }
 
 type CommandOptionFunc func(*Command)
@@ -233,9 +816,153 @@ func WithCommandTimeout(timeout time.Duration) CommandOptionFunc {
 	}
 }
 
+// WithCommandFallbackTimeout 设置降级函数的超时时间，没有设置时与功能函数的超时时间相同。
+// 降级函数（如读取缓存）通常比功能函数快得多，可以设置更短的超时时间，以免在功能函数超时后再等待同样长的时间。
+func WithCommandFallbackTimeout(timeout time.Duration) CommandOptionFunc {
+	return func(c *Command) {
+		c.fallbackTimeout = &timeout
+	}
+}
+
+// WithCommandMaxConcurrency 用于为Command设置最大并发执行数量（舱壁隔离），与熔断器相互独立。
+// 并发执行数量已满时，新的请求将记录一次失败，并直接走降级逻辑，返回的错误包装了ErrMaxConcurrency。
+func WithCommandMaxConcurrency(n int) CommandOptionFunc {
+	return func(c *Command) {
+		c.semaphore = make(chan struct{}, n)
+	}
+}
+
+// WithCommandMaxStuckInFlight 用于为Command设置允许超时（或被取消）后仍未返回的最大执行数量，需要同时设置超时时间。
+// 功能函数不响应context的取消时，超时后goroutine依然会继续执行，超过该数量后新的请求将记录一次失败，
+// 并直接走降级逻辑，返回的错误包装了ErrStuck；与最大并发数量不同，只针对挂起的执行。
+func WithCommandMaxStuckInFlight(n int) CommandOptionFunc {
+	return func(c *Command) {
+		c.maxStuckInFlight = int64(n)
+	}
+}
+
+// WithCommandRetry 用于为Command设置功能函数失败后的重试。
+// maxAttempts 为功能函数最多执行的次数（含第一次）；backoff 返回第attempt次失败后到下一次重试前的等待时间，可为nil表示不等待。
+// 所有重试共享Command的超时时间，熔断器只记录最终的结果。
+func WithCommandRetry(maxAttempts int, backoff func(attempt int) time.Duration) CommandOptionFunc {
+	return func(c *Command) {
+		c.retryMaxAttempts = maxAttempts
+		c.retryBackoff = backoff
+	}
+}
+
+// WithCommandIdempotent 用于标记Command的功能函数是否幂等（默认是）。
+// 标记为不幂等（如写操作）时，即使设置了重试也只执行一次，以免重复写入。
+func WithCommandIdempotent(idempotent bool) CommandOptionFunc {
+	return func(c *Command) {
+		c.idempotent = idempotent
+	}
+}
+
+// WithCommandFailureFilter 用于为Command设置判断错误是否计为失败的函数。
+// 功能函数返回错误时，如果该函数返回false（如调用方断开导致的context.Canceled、参数校验失败等），
+// 熔断器将记为一次成功，也不会执行降级函数，错误原样返回给调用方。
+func WithCommandFailureFilter(filter func(error) bool) CommandOptionFunc {
+	return func(c *Command) {
+		c.failureFilter = filter
+	}
+}
+
+// WithCommandSuccessPredicate 用于为Command设置判断执行结果是否成功的函数，用于结果本身表示失败的情况（如没有转换为错误的HTTP 503）。
+// 功能函数没有返回错误但该函数返回false时，按功能函数失败处理：熔断器记为一次失败，执行降级函数（如有），返回的错误包装了ErrResultFailure；
+// 功能函数返回了错误时，依然按错误处理，不受该函数的返回值影响。
+func WithCommandSuccessPredicate(predicate func(result interface{}, err error) bool) CommandOptionFunc {
+	return func(c *Command) {
+		c.successPredicate = predicate
+	}
+}
+
+// WithCommandConfirmFunc 用于为Command设置确认函数，用于对幂等敏感的操作。
+// 功能函数超时后，先通过该函数确认操作实际是否已经完成，已完成时不再执行降级函数，返回nil结果和nil错误；
+// 熔断器依然记录一次超时。
+func WithCommandConfirmFunc(confirm CommandConfirmFunc) CommandOptionFunc {
+	return func(c *Command) {
+		c.confirm = confirm
+	}
+}
+
+// WithCommandContextEnricher 用于为Command设置context附加函数，用于集中为每次执行附加统一的值（如租户信息）。
+// 该函数在每次执行的最开始调用，附加后的context将传给功能函数；降级函数与确认函数新建的context也会经过该函数。
+func WithCommandContextEnricher(enricher func(context.Context) context.Context) CommandOptionFunc {
+	return func(c *Command) {
+		c.contextEnricher = enricher
+	}
+}
+
+// WithCommandEventChannel 用于为Command设置事件channel，每次执行的结果都会以 Event 发布到该channel，便于自定义聚合处理。
+// dropIfFull 为true时，channel已满将直接丢弃事件，不会阻塞执行；为false时将阻塞直至事件被接收，请确保及时消费。
+func WithCommandEventChannel(ch chan<- Event, dropIfFull bool) CommandOptionFunc {
+	return func(c *Command) {
+		c.eventCh = ch
+		c.eventDropIfFull = dropIfFull
+	}
+}
+
+// WithCommandRecoverPanic 用于设置是否恢复功能函数与降级函数的panic（默认否，统计后继续panic）。
+// 设置后panic将转换为 PanicError 按失败处理：功能函数panic记为失败并执行降级函数，降级函数panic记为降级失败并返回该错误。
+func WithCommandRecoverPanic(recoverPanic bool) CommandOptionFunc {
+	return func(c *Command) {
+		c.recoverPanic = recoverPanic
+	}
+}
+
+// WithCommandLogger 用于为Command设置日志，熔断开启、半开探测放行、拒绝请求、执行降级函数、功能函数panic时输出日志。
+// 成功的执行不输出日志；熔断器实现了 breaker.StateNotifier（如 CutBreaker）时，状态变化的日志在熔断器切换状态后异步输出，
+// 否则每次执行会额外读取熔断器状态，通过比较执行前后的状态发现状态变化，并发执行时可能重复或遗漏。
+func WithCommandLogger(logger Logger) CommandOptionFunc {
+	return func(c *Command) {
+		c.logger = logger
+	}
+}
+
+// WithCommandLastResort 用于为Command设置功能函数与降级函数都失败时的最后处理函数。
+// 默认返回降级函数的错误；设置后将返回该函数的结果，可用于组合错误或返回兜底的默认值。
+// runErr 为功能函数的错误（或熔断开启等错误），fallbackErr 为降级函数的错误。
+func WithCommandLastResort(lastResort func(param interface{}, runErr, fallbackErr error) (interface{}, error)) CommandOptionFunc {
+	return func(c *Command) {
+		c.lastResort = lastResort
+	}
+}
+
 // WithCommandBreaker 用于为Command设置降级函数。
 func WithCommandFallback(fallback CommandFallbackFunc) CommandOptionFunc {
 	return func(c *Command) {
 		c.fallback = fallback
 	}
 }
+
+// WithCommandFallbackChain 用于为Command设置按顺序执行的多个降级函数（如先查缓存、再返回静态默认值）。
+// 前一个降级函数返回错误时执行下一个，每个降级函数收到的都是功能函数的原始错误，返回第一个成功的结果，都失败时返回最后一个的错误。
+// 所有降级函数共享一次超时时间，熔断器只按最终结果记录一次降级成功/失败。
+func WithCommandFallbackChain(fallbacks ...CommandFallbackFunc) CommandOptionFunc {
+	return func(c *Command) {
+		if len(fallbacks) == 0 {
+			c.fallback = nil
+			return
+		}
+		c.fallback = func(ctx context.Context, param interface{}, err error) (interface{}, error) {
+			var res interface{}
+			var fallbackErr error
+			for _, fallback := range fallbacks {
+				if res, fallbackErr = fallback(ctx, param, err); fallbackErr == nil {
+					return res, nil
+				}
+			}
+			return res, fallbackErr
+		}
+	}
+}
+
+// WithCommandErrorKeyFunc 用于为Command设置错误特征函数，开启按错误特征统计失败次数。
+// 功能函数返回错误时，将通过该函数提取错误特征（如错误信息中的下游节点id）并计数，
+// 出现次数最多的错误特征可通过Summary/DrainStats中的TopErrorKeys获取。
+func WithCommandErrorKeyFunc(keyFunc func(error) string) CommandOptionFunc {
+	return func(c *Command) {
+		c.errorKeys = newErrorKeyCounter(keyFunc)
+	}
+}
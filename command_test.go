@@ -4,8 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/bunnier/circuit/breaker"
 )
 
 func TestCommand_workflow(t *testing.T) {
@@ -48,13 +54,13 @@ func TestCommand_workflow(t *testing.T) {
 	}
 
 	// 再一个熔断。
-	if _, err := command.Execute(10001); err == nil || err.Error() != "fallback: test: open: command: unavailable" {
-		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: test: open: command: unavailable")
+	if _, err := command.Execute(10001); err == nil || err.Error() != "fallback: test: open: command: circuit open" {
+		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: test: open: command: circuit open")
 	}
 
 	// 熔断中，正常的也熔断。
-	if _, err := command.Execute(1); err == nil || err.Error() != "fallback: test: open: command: unavailable" {
-		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: test: open: command: unavailable")
+	if _, err := command.Execute(1); err == nil || err.Error() != "fallback: test: open: command: circuit open" {
+		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: test: open: command: circuit open")
 	}
 
 	time.Sleep(5 * time.Second)
@@ -63,8 +69,8 @@ func TestCommand_workflow(t *testing.T) {
 		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: more then 5000")
 	}
 	// 由于刚放了个错误的进行半熔断测试，又恢复熔断了。
-	if _, err := command.Execute(1); err == nil || err.Error() != "fallback: test: open: command: unavailable" {
-		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: test: open: command: unavailable")
+	if _, err := command.Execute(1); err == nil || err.Error() != "fallback: test: open: command: circuit open" {
+		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: test: open: command: circuit open")
 	}
 
 	time.Sleep(5 * time.Second)
@@ -119,13 +125,13 @@ func TestCommand_withtimeout_workflow(t *testing.T) {
 	}
 
 	// 再一个熔断。
-	if _, err := command.Execute(10001); err == nil || err.Error() != "fallback: test: open: command: unavailable" {
-		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: test: open: command: unavailable")
+	if _, err := command.Execute(10001); err == nil || err.Error() != "fallback: test: open: command: circuit open" {
+		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: test: open: command: circuit open")
 	}
 
 	// 熔断中，正常的也熔断。
-	if _, err := command.Execute(1); err == nil || err.Error() != "fallback: test: open: command: unavailable" {
-		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: test: open: command: unavailable")
+	if _, err := command.Execute(1); err == nil || err.Error() != "fallback: test: open: command: circuit open" {
+		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: test: open: command: circuit open")
 	}
 
 	time.Sleep(5 * time.Second)
@@ -134,8 +140,8 @@ func TestCommand_withtimeout_workflow(t *testing.T) {
 		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: more then 5000")
 	}
 	// 由于刚放了个错误的进行半熔断测试，又恢复熔断了。
-	if _, err := command.Execute(1); err == nil || err.Error() != "fallback: test: open: command: unavailable" {
-		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: test: open: command: unavailable")
+	if _, err := command.Execute(1); err == nil || err.Error() != "fallback: test: open: command: circuit open" {
+		t.Errorf("Command.Execute() got = %v, want %v", err, "fallback: test: open: command: circuit open")
 	}
 
 	time.Sleep(5 * time.Second)
@@ -212,3 +218,1206 @@ func TestCommand_fallback_timeout(t *testing.T) {
 		t.Errorf("Command.Execute() got = %v, want nil", err)
 	}
 }
+
+func TestCommand_timeout_stage(t *testing.T) {
+	t.Parallel()
+	// 功能函数，参数为执行的毫秒数。
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		time.Sleep(time.Millisecond * time.Duration(i.(int)))
+		return nil, errors.New("must err")
+	}
+	// 降级函数，参数为执行的毫秒数。
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		if !errors.Is(e, ErrTimeout) {
+			time.Sleep(time.Millisecond * 500)
+		}
+		return nil, e
+	}
+	// 初始化Command。
+	command := NewCommand("test", run,
+		WithCommandFallback(fallback),
+		WithCommandTimeout(time.Millisecond*200))
+	defer command.Close()
+
+	tests := []struct {
+		name    string
+		timeout time.Duration // 调用方传入的超时时间，0为不设置。
+		param   int
+		stage   TimeoutStage
+	}{
+		{"run", 0, 500, StageRun},
+		{"fallback", 0, 10, StageFallback},
+		{"overall", time.Millisecond * 100, 500, StageOverall},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				ctxWt, cancel := context.WithTimeout(ctx, tt.timeout)
+				ctx = ctxWt
+				defer cancel()
+			}
+
+			_, err := command.ContextExecute(ctx, tt.param)
+			if !errors.Is(err, ErrTimeout) {
+				t.Fatalf("Command.ContextExecute() got = %v, want %v", err, ErrTimeout)
+			}
+			var timeoutErr *TimeoutError
+			if !errors.As(err, &timeoutErr) {
+				t.Fatalf("Command.ContextExecute() got = %T, want %T", err, timeoutErr)
+			}
+			if timeoutErr.Stage != tt.stage {
+				t.Errorf("TimeoutError.Stage got = %v, want %v", timeoutErr.Stage, tt.stage)
+			}
+		})
+	}
+}
+
+// TestCommand_fallbackTimeout 测试降级函数使用单独设置的更短超时时间。
+func TestCommand_fallbackTimeout(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return nil, errors.New("must err")
+	}
+	// 降级函数，参数为执行的毫秒数。
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		time.Sleep(time.Millisecond * time.Duration(i.(int)))
+		return "fallback", nil
+	}
+	command := NewCommand("test", run,
+		WithCommandFallback(fallback),
+		WithCommandTimeout(time.Second),
+		WithCommandFallbackTimeout(time.Millisecond*50))
+	defer command.Close()
+
+	if res, err := command.Execute(10); err != nil || res != "fallback" {
+		t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "fallback")
+	}
+
+	// 降级函数超过自己的超时时间，尽管功能函数的超时时间还很充足。
+	start := time.Now()
+	_, err := command.Execute(200)
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Stage != StageFallback {
+		t.Errorf("Command.Execute() got = %v, want %v", err, &TimeoutError{"test", StageFallback})
+	}
+	if elapsed := time.Since(start); elapsed > time.Millisecond*150 {
+		t.Errorf("Command.Execute() elapsed got = %v, want about %v", elapsed, time.Millisecond*50)
+	}
+}
+
+// Test_wrapCommandFallbackFuncWithTimeout 测试降级函数的超时包装不依赖调用方传入带超时的context。
+func Test_wrapCommandFallbackFuncWithTimeout(t *testing.T) {
+	t.Parallel()
+	command := NewCommand("test", nil)
+	defer command.Close()
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		<-ctx.Done() // 一直等到超时。
+		return "fallback", nil
+	}
+
+	wrapped := wrapCommandFallbackFuncWithTimeout(command, fallback, time.Millisecond*20)
+	resCh := make(chan error, 1)
+	go func() {
+		_, err := wrapped(context.Background(), 1, nil)
+		resCh <- err
+	}()
+	select {
+	case err := <-resCh:
+		var timeoutErr *TimeoutError
+		if !errors.As(err, &timeoutErr) || timeoutErr.Stage != StageFallback {
+			t.Errorf("wrapCommandFallbackFuncWithTimeout() got = %v, want %v", err, &TimeoutError{"test", StageFallback})
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("wrapCommandFallbackFuncWithTimeout() with background context hangs")
+	}
+}
+
+func TestCommand_DrainStats(t *testing.T) {
+	t.Parallel()
+	// 功能函数，简单的通过参数true/false来控制成功失败。
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if success := i.(bool); !success {
+			return nil, errors.New("error")
+		}
+		return "ok", nil
+	}
+	command := NewCommand("test", run)
+	defer command.Close()
+
+	intervals := []struct {
+		success int64
+		failure int64
+	}{
+		{6, 4},
+		{5, 0},
+	}
+	for i, interval := range intervals {
+		for j := int64(0); j < interval.success; j++ {
+			command.Execute(true)
+		}
+		for j := int64(0); j < interval.failure; j++ {
+			command.Execute(false)
+		}
+		time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+
+		summary := command.DrainStats()
+		if summary.Success != interval.success {
+			t.Errorf("interval%d: Command.DrainStats() Success got = %d, want %d", i, summary.Success, interval.success)
+		}
+		if summary.Failure != interval.failure {
+			t.Errorf("interval%d: Command.DrainStats() Failure got = %d, want %d", i, summary.Failure, interval.failure)
+		}
+	}
+}
+
+// TestCommand_DrainStatsKeepsBreakerWindow 测试按周期推送统计数据不会重置熔断器的判断窗口。
+func TestCommand_DrainStatsKeepsBreakerWindow(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return nil, errors.New("error")
+	}
+	command := NewCommand("test", run) // 默认10个请求起算。
+	defer command.Close()
+
+	for i := 0; i < 10; i++ {
+		command.Execute(nil)
+		command.DrainStats() // 推送周期比请求间隔还短。
+	}
+	if _, err := command.Execute(nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Command.Execute() got = %v, want %v", err, ErrCircuitOpen)
+	}
+	if summary := command.Summary(); summary.Failure != 10 {
+		t.Errorf("Command.Summary() Failure got = %d, want %d", summary.Failure, 10)
+	}
+}
+
+// TestCommand_timeout_cancel 测试超时后传入功能函数的context会被及时取消。
+func TestCommand_timeout_cancel(t *testing.T) {
+	t.Parallel()
+	cancelledCh := make(chan time.Time, 1)
+	// 功能函数，响应context的取消。
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		select {
+		case <-ctx.Done():
+			cancelledCh <- time.Now()
+			return nil, ctx.Err()
+		case <-time.After(time.Second * 5):
+			return nil, nil
+		}
+	}
+	command := NewCommand("test", run, WithCommandTimeout(time.Millisecond*100))
+	defer command.Close()
+
+	if _, err := command.Execute(nil); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Command.Execute() got = %v, want %v", err, ErrTimeout)
+	}
+	timeoutTime := time.Now()
+
+	select {
+	case cancelledTime := <-cancelledCh:
+		if cancelledTime.Sub(timeoutTime) > time.Millisecond*50 {
+			t.Errorf("run context cancelled %v after timeout, want promptly", cancelledTime.Sub(timeoutTime))
+		}
+	case <-time.After(time.Second):
+		t.Errorf("run context not cancelled after timeout")
+	}
+}
+
+func TestCommand_maxConcurrency(t *testing.T) {
+	t.Parallel()
+	startedCh := make(chan struct{})
+	releaseCh := make(chan struct{})
+	// 功能函数，参数为true时阻塞到releaseCh关闭，为false时panic。
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if !i.(bool) {
+			panic("must panic")
+		}
+		startedCh <- struct{}{}
+		<-releaseCh
+		return "ok", nil
+	}
+	command := NewCommand("test", run, WithCommandMaxConcurrency(2))
+	defer command.Close()
+
+	// 占满信号量。
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			command.Execute(true)
+			wg.Done()
+		}()
+		<-startedCh
+	}
+
+	// 超出的请求立即返回。
+	for i := 0; i < 3; i++ {
+		if _, err := command.Execute(true); !errors.Is(err, ErrMaxConcurrency) {
+			t.Errorf("Command.Execute() got = %v, want %v", err, ErrMaxConcurrency)
+		}
+	}
+
+	close(releaseCh)
+	wg.Wait()
+
+	// panic后也能释放信号量。
+	for i := 0; i < 3; i++ {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Command.Execute() want panic")
+				}
+			}()
+			command.Execute(false)
+		}()
+	}
+
+	go func() { <-startedCh }()
+	if res, err := command.Execute(true); err != nil || res != "ok" {
+		t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "ok")
+	}
+}
+
+func TestCommand_ContextExecuteWithBudget(t *testing.T) {
+	t.Parallel()
+	// 功能函数，参数为执行的毫秒数。
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		time.Sleep(time.Millisecond * time.Duration(i.(int)))
+		return i, nil
+	}
+	command := NewCommand("test", run, WithCommandTimeout(time.Second))
+	defer command.Close()
+
+	// 使用Command的超时时间作为预算，执行越慢剩余越少。
+	_, fastRemaining, err := command.ContextExecuteWithBudget(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("Command.ContextExecuteWithBudget() got = %v, want nil", err)
+	}
+	_, slowRemaining, err := command.ContextExecuteWithBudget(context.Background(), 300)
+	if err != nil {
+		t.Fatalf("Command.ContextExecuteWithBudget() got = %v, want nil", err)
+	}
+	if fastRemaining > time.Millisecond*900 || fastRemaining < time.Millisecond*800 {
+		t.Errorf("Command.ContextExecuteWithBudget() remaining got = %v, want about %v", fastRemaining, time.Millisecond*900)
+	}
+	if slowRemaining >= fastRemaining {
+		t.Errorf("Command.ContextExecuteWithBudget() remaining got = %v, want less than %v", slowRemaining, fastRemaining)
+	}
+
+	// ctx的截止时间更早时，以ctx为准。
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+	_, remaining, _ := command.ContextExecuteWithBudget(ctx, 100)
+	if remaining > time.Millisecond*400 || remaining < time.Millisecond*300 {
+		t.Errorf("Command.ContextExecuteWithBudget() remaining got = %v, want about %v", remaining, time.Millisecond*400)
+	}
+
+	// 预算耗尽。
+	_, remaining, err = command.ContextExecuteWithBudget(context.Background(), 1200)
+	if !errors.Is(err, ErrTimeout) || remaining != 0 {
+		t.Errorf("Command.ContextExecuteWithBudget() got = %v, %v, want %v, %v", remaining, err, 0, ErrTimeout)
+	}
+}
+
+func TestCommand_retry(t *testing.T) {
+	t.Parallel()
+	// 功能函数，前param次执行失败。
+	var attempts int64
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if atomic.AddInt64(&attempts, 1) <= int64(i.(int)) {
+			return nil, errors.New("must err")
+		}
+		return "ok", nil
+	}
+	// 降级函数。
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		return "fallback", nil
+	}
+	backoff := func(attempt int) time.Duration {
+		return time.Millisecond * 10 * time.Duration(attempt)
+	}
+
+	t.Run("success on second attempt", func(t *testing.T) {
+		atomic.StoreInt64(&attempts, 0)
+		command := NewCommand("test", run, WithCommandRetry(3, backoff), WithCommandFallback(fallback))
+		defer command.Close()
+
+		if res, err := command.Execute(1); err != nil || res != "ok" {
+			t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "ok")
+		}
+		if got := atomic.LoadInt64(&attempts); got != 2 {
+			t.Errorf("attempts got = %d, want %d", got, 2)
+		}
+		time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+		if summary := command.Summary(); summary.Success != 1 || summary.Failure != 0 {
+			t.Errorf("Command.Summary() got = %d/%d, want %d/%d", summary.Success, summary.Failure, 1, 0)
+		}
+	})
+
+	t.Run("exhaustion goes to fallback", func(t *testing.T) {
+		atomic.StoreInt64(&attempts, 0)
+		command := NewCommand("test", run, WithCommandRetry(3, backoff), WithCommandFallback(fallback))
+		defer command.Close()
+
+		if res, err := command.Execute(5); err != nil || res != "fallback" {
+			t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "fallback")
+		}
+		if got := atomic.LoadInt64(&attempts); got != 3 {
+			t.Errorf("attempts got = %d, want %d", got, 3)
+		}
+		time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+		if summary := command.Summary(); summary.Failure != 1 || summary.FallbackSuccess != 1 {
+			t.Errorf("Command.Summary() got = %d/%d, want %d/%d", summary.Failure, summary.FallbackSuccess, 1, 1)
+		}
+	})
+
+	t.Run("non-idempotent does not retry", func(t *testing.T) {
+		atomic.StoreInt64(&attempts, 0)
+		command := NewCommand("test", run,
+			WithCommandRetry(3, backoff),
+			WithCommandIdempotent(false),
+			WithCommandFallback(fallback))
+		defer command.Close()
+
+		if res, err := command.Execute(1); err != nil || res != "fallback" {
+			t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "fallback")
+		}
+		if got := atomic.LoadInt64(&attempts); got != 1 {
+			t.Errorf("attempts got = %d, want %d", got, 1)
+		}
+	})
+
+	t.Run("retries respect timeout", func(t *testing.T) {
+		atomic.StoreInt64(&attempts, 0)
+		command := NewCommand("test", run,
+			WithCommandRetry(3, func(attempt int) time.Duration { return time.Second }),
+			WithCommandTimeout(time.Millisecond*200))
+		defer command.Close()
+
+		startTime := time.Now()
+		if _, err := command.Execute(5); !errors.Is(err, ErrTimeout) {
+			t.Errorf("Command.Execute() got = %v, want %v", err, ErrTimeout)
+		}
+		if elapsed := time.Since(startTime); elapsed > time.Millisecond*300 {
+			t.Errorf("Command.Execute() elapsed = %v, want less than %v", elapsed, time.Millisecond*300)
+		}
+		if got := atomic.LoadInt64(&attempts); got != 1 {
+			t.Errorf("attempts got = %d, want %d", got, 1)
+		}
+	})
+}
+
+func TestCommand_confirm(t *testing.T) {
+	t.Parallel()
+	// 模拟一个写操作：服务端已经写入，但响应超时。
+	var written int64
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		atomic.StoreInt64(&written, 1)
+		time.Sleep(time.Millisecond * 200)
+		return "ok", nil
+	}
+	confirm := func(ctx context.Context, i interface{}) (bool, error) {
+		return atomic.LoadInt64(&written) == 1, nil
+	}
+	var fallbackCount int64
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		atomic.AddInt64(&fallbackCount, 1)
+		return "fallback", nil
+	}
+
+	t.Run("confirmed skips fallback", func(t *testing.T) {
+		command := NewCommand("test", run,
+			WithCommandTimeout(time.Millisecond*50),
+			WithCommandConfirmFunc(confirm),
+			WithCommandFallback(fallback))
+		defer command.Close()
+
+		if res, err := command.Execute(1); err != nil || res != nil {
+			t.Errorf("Command.Execute() got = %v, %v, want nil, nil", res, err)
+		}
+		if got := atomic.LoadInt64(&fallbackCount); got != 0 {
+			t.Errorf("fallback count got = %d, want %d", got, 0)
+		}
+		time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+		if summary := command.Summary(); summary.Timeout != 1 {
+			t.Errorf("Command.Summary() Timeout got = %d, want %d", summary.Timeout, 1)
+		}
+	})
+
+	t.Run("unconfirmed goes to fallback", func(t *testing.T) {
+		command := NewCommand("test", run,
+			WithCommandTimeout(time.Millisecond*50),
+			WithCommandConfirmFunc(func(ctx context.Context, i interface{}) (bool, error) {
+				return false, errors.New("unknown")
+			}),
+			WithCommandFallback(fallback))
+		defer command.Close()
+
+		if res, err := command.Execute(1); err != nil || res != "fallback" {
+			t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "fallback")
+		}
+	})
+}
+
+func TestCommand_maxStuckInFlight(t *testing.T) {
+	t.Parallel()
+	// 不响应context取消的功能函数，一直挂起直到release被关闭。
+	release := make(chan struct{})
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if i.(bool) {
+			<-release
+		}
+		return "ok", nil
+	}
+	command := NewCommand("test", run,
+		WithCommandTimeout(time.Millisecond*20),
+		WithCommandMaxStuckInFlight(2))
+	defer command.Close()
+
+	// 前3次超时，挂起数量依次增加到3。
+	for i := 0; i < 3; i++ {
+		if _, err := command.Execute(true); !errors.Is(err, ErrTimeout) {
+			t.Errorf("Command.Execute() got = %v, want %v", err, ErrTimeout)
+		}
+	}
+
+	// 挂起数量超过2，快速失败，不再等待超时。
+	startTime := time.Now()
+	if _, err := command.Execute(false); !errors.Is(err, ErrStuck) {
+		t.Errorf("Command.Execute() got = %v, want %v", err, ErrStuck)
+	}
+	if elapsed := time.Since(startTime); elapsed >= time.Millisecond*20 {
+		t.Errorf("Command.Execute() elapsed = %v, want less than %v", elapsed, time.Millisecond*20)
+	}
+
+	// 挂起的执行返回后，恢复正常。
+	close(release)
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保挂起的goroutine都已返回。
+	if res, err := command.Execute(false); err != nil || res != "ok" {
+		t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "ok")
+	}
+}
+
+func TestCommand_contextEnricher(t *testing.T) {
+	t.Parallel()
+	type tenantKey struct{}
+	enricher := func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, tenantKey{}, "tenant-1")
+	}
+	// 功能函数返回context中的值，param为true时返回错误以进入降级函数。
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if i.(bool) {
+			return nil, errors.New("must err")
+		}
+		return ctx.Value(tenantKey{}), nil
+	}
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		return ctx.Value(tenantKey{}), nil
+	}
+
+	for _, timeout := range []time.Duration{0, time.Second} { // 有无超时包装时都应该生效。
+		options := []CommandOptionFunc{WithCommandContextEnricher(enricher), WithCommandFallback(fallback)}
+		if timeout > 0 {
+			options = append(options, WithCommandTimeout(timeout))
+		}
+		command := NewCommand("test", run, options...)
+
+		if res, err := command.Execute(false); err != nil || res != "tenant-1" {
+			t.Errorf("Command.Execute() run got = %v, %v, want %v, nil", res, err, "tenant-1")
+		}
+		if res, err := command.Execute(true); err != nil || res != "tenant-1" {
+			t.Errorf("Command.Execute() fallback got = %v, %v, want %v, nil", res, err, "tenant-1")
+		}
+		command.Close()
+	}
+}
+
+func TestCommand_Reset(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return nil, errors.New("must err")
+	}
+	command := NewCommand("test", run)
+	defer command.Close()
+
+	// 默认熔断器10个请求起算，失败率100%，熔断开启。
+	for i := 0; i < 10; i++ {
+		command.Execute(i)
+	}
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+	if _, err := command.Execute(1); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Command.Execute() got = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	// 重置后不需要等待休眠时间，立即关闭。
+	command.Reset()
+	if pass, statusMsg := command.breaker.Allow(); !pass || statusMsg != "closed" {
+		t.Errorf("Breaker.Allow() got = %v, %v, want %v, %v", pass, statusMsg, true, "closed")
+	}
+}
+
+func TestCommand_lastResort(t *testing.T) {
+	t.Parallel()
+	runErr := errors.New("run err")
+	fallbackErr := errors.New("fallback err")
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return nil, runErr
+	}
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		return nil, fallbackErr
+	}
+	var gotParam interface{}
+	var gotRunErr, gotFallbackErr error
+	lastResort := func(param interface{}, runErr, fallbackErr error) (interface{}, error) {
+		gotParam, gotRunErr, gotFallbackErr = param, runErr, fallbackErr
+		return "default", nil
+	}
+	command := NewCommand("test", run, WithCommandFallback(fallback), WithCommandLastResort(lastResort))
+	defer command.Close()
+
+	if res, err := command.Execute(1); err != nil || res != "default" {
+		t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "default")
+	}
+	if gotParam != 1 || gotRunErr != runErr || gotFallbackErr != fallbackErr {
+		t.Errorf("lastResort() args got = %v, %v, %v, want %v, %v, %v", gotParam, gotRunErr, gotFallbackErr, 1, runErr, fallbackErr)
+	}
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+	if summary := command.Summary(); summary.Failure != 1 {
+		t.Errorf("Command.Summary() Failure got = %d, want %d", summary.Failure, 1)
+	}
+}
+
+func TestCommand_fallbackChain(t *testing.T) {
+	t.Parallel()
+	runErr := errors.New("run err")
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return nil, runErr
+	}
+	// 第一个降级函数（缓存）失败，第二个降级函数（静态默认值）成功，两者收到的都是功能函数的原始错误。
+	var receivedErrs []error
+	cacheFallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		receivedErrs = append(receivedErrs, e)
+		return nil, errors.New("cache miss")
+	}
+	staticFallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		receivedErrs = append(receivedErrs, e)
+		return "static", nil
+	}
+	command := NewCommand("test", run, WithCommandFallbackChain(cacheFallback, staticFallback))
+	defer command.Close()
+
+	if res, err := command.Execute(1); err != nil || res != "static" {
+		t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "static")
+	}
+	if len(receivedErrs) != 2 || receivedErrs[0] != runErr || receivedErrs[1] != runErr {
+		t.Errorf("fallback received errors got = %v, want %v", receivedErrs, []error{runErr, runErr})
+	}
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+	if summary := command.Summary(); summary.FallbackSuccess != 1 {
+		t.Errorf("Command.Summary() FallbackSuccess got = %d, want %d", summary.FallbackSuccess, 1)
+	}
+
+	// 都失败时返回最后一个的错误。
+	command = NewCommand("test", run, WithCommandFallbackChain(cacheFallback, cacheFallback))
+	defer command.Close()
+	if _, err := command.Execute(1); err == nil || err.Error() != "cache miss" {
+		t.Errorf("Command.Execute() got = %v, want %v", err, "cache miss")
+	}
+}
+
+func TestCommand_ExecuteWithTimeout(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second * 2):
+			return "ok", nil
+		}
+	}
+	command := NewCommand("test", run, WithCommandTimeout(time.Second*10))
+	defer command.Close()
+
+	startTime := time.Now()
+	if _, err := command.ExecuteWithTimeout(1, time.Millisecond*500); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Command.ExecuteWithTimeout() got = %v, want %v", err, ErrTimeout)
+	}
+	if elapsed := time.Since(startTime); elapsed > time.Second {
+		t.Errorf("Command.ExecuteWithTimeout() elapsed = %v, want about %v", elapsed, time.Millisecond*500)
+	}
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+	if summary := command.Summary(); summary.Timeout != 1 {
+		t.Errorf("Command.Summary() Timeout got = %d, want %d", summary.Timeout, 1)
+	}
+
+	// 默认超时时间不受影响。
+	if res, err := command.Execute(1); err != nil || res != "ok" {
+		t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "ok")
+	}
+}
+
+func TestCommand_circuitOpen(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return i, nil
+	}
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		return nil, e
+	}
+
+	for _, options := range [][]CommandOptionFunc{nil, {WithCommandFallback(fallback)}} { // 有无降级函数时都一样。
+		command := NewCommand("test", run, options...)
+		command.breaker.ForceOpen()
+
+		_, err := command.Execute(1)
+		if !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Command.Execute() got = %v, want %v", err, ErrCircuitOpen)
+		}
+		if !errors.Is(err, ErrUnavailable) { // 保持兼容。
+			t.Errorf("Command.Execute() got = %v, want %v", err, ErrUnavailable)
+		}
+		if err.Error() != "test: forced-open: command: circuit open" {
+			t.Errorf("Command.Execute() got = %v, want %v", err, "test: forced-open: command: circuit open")
+		}
+		command.Close()
+	}
+}
+
+// TestCommand_successPredicate 测试没有返回错误但结果表示失败时，同样记为失败并开启熔断器。
+func TestCommand_successPredicate(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return i, nil // 直接返回状态码，不转换为错误。
+	}
+	predicate := func(result interface{}, err error) bool {
+		return result.(int) < 500
+	}
+
+	for _, options := range [][]CommandOptionFunc{nil, {WithCommandTimeout(time.Second)}} { // 有无超时时间时都一样。
+		command := NewCommand("test", run, append(options,
+			WithCommandSuccessPredicate(predicate),
+			WithCommandBreaker(breaker.NewCutBreaker("test",
+				breaker.WithCutBreakerTimeWindow(5*time.Second),
+				breaker.WithCutBreakerErrorThresholdPercentage(50),
+				breaker.WithCutBreakerMinRequestThreshold(4))))...)
+
+		if res, err := command.Execute(200); err != nil || res != 200 {
+			t.Errorf("Command.Execute(200) got = %v, %v, want %v, nil", res, err, 200)
+		}
+		for i := 0; i < 3; i++ {
+			if _, err := command.Execute(503); !errors.Is(err, ErrResultFailure) {
+				t.Errorf("Command.Execute(503) got = %v, want %v", err, ErrResultFailure)
+			}
+		}
+		if summary := command.Summary(); summary.Success != 1 || summary.Failure != 3 {
+			t.Errorf("Command.Summary() got = %d/%d, want %d/%d", summary.Success, summary.Failure, 1, 3)
+		}
+
+		// 失败的结果达到阈值，熔断器开启。
+		if _, err := command.Execute(200); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Command.Execute() after trip got = %v, want %v", err, ErrCircuitOpen)
+		}
+		command.Close()
+	}
+
+	// 降级函数收到包装了ErrResultFailure的错误。
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		if errors.Is(e, ErrResultFailure) {
+			return "fallback", nil
+		}
+		return nil, e
+	}
+	command := NewCommand("test", run, WithCommandSuccessPredicate(predicate), WithCommandFallback(fallback))
+	defer command.Close()
+	if res, err := command.Execute(503); err != nil || res != "fallback" {
+		t.Errorf("Command.Execute(503) with fallback got = %v, %v, want %v, nil", res, err, "fallback")
+	}
+}
+
+// TestCommand_fallbackOnCircuitOpen 测试降级函数可以区分熔断器开启与功能函数执行失败，只在熔断时使用缓存。
+func TestCommand_fallbackOnCircuitOpen(t *testing.T) {
+	t.Parallel()
+	runErr := errors.New("run error")
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if i.(bool) {
+			return "ok", nil
+		}
+		return nil, runErr
+	}
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		if errors.Is(e, ErrCircuitOpen) {
+			return "cache", nil // 熔断时使用缓存。
+		}
+		return nil, e // 功能函数真正的错误原样抛出。
+	}
+	command := NewCommand("test", run, WithCommandFallback(fallback))
+	defer command.Close()
+
+	if res, err := command.Execute(false); res != nil || err != runErr {
+		t.Errorf("Command.Execute() got = %v, %v, want %v, %v", res, err, nil, runErr)
+	}
+
+	command.breaker.ForceOpen()
+	for _, param := range []bool{true, false} { // 熔断时无论参数如何都不会执行功能函数。
+		if res, err := command.Execute(param); res != "cache" || err != nil {
+			t.Errorf("Command.Execute() got = %v, %v, want %v, %v", res, err, "cache", nil)
+		}
+	}
+}
+
+func TestCommand_Close(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return i, nil
+	}
+	command := NewCommand("test", run)
+
+	var closer io.Closer = command // 可以作为 io.Closer 使用。
+
+	select {
+	case <-command.Done():
+		t.Fatalf("Command.Done() closed before Close()")
+	default:
+	}
+	if command.Closed() {
+		t.Errorf("Command.Closed() got = %v, want %v", true, false)
+	}
+
+	if err := closer.Close(); err != nil {
+		t.Errorf("Command.Close() got = %v, want nil", err)
+	}
+	select {
+	case <-command.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Command.Done() not closed after Close()")
+	}
+
+	if !command.Closed() {
+		t.Errorf("Command.Closed() got = %v, want %v", false, true)
+	}
+
+	if err := closer.Close(); err != nil { // 重复调用也没有问题。
+		t.Errorf("Command.Close() again got = %v, want nil", err)
+	}
+
+	// 释放后不能再执行。
+	if _, err := command.Execute(1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Command.Execute() after Close got = %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestCommand_Drain(t *testing.T) {
+	t.Parallel()
+	started, finish := make(chan struct{}), make(chan struct{})
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if i.(bool) { // 慢执行，等待通知后才返回。
+			close(started)
+			<-finish
+		}
+		return i, nil
+	}
+	command := NewCommand("test", run)
+	defer command.Close()
+
+	// 没有正在执行的时候，Drain 后 Wait 立即返回。
+	idle := NewCommand("idle", run)
+	defer idle.Close()
+	idle.Drain()
+	if err := idle.Wait(context.Background()); err != nil {
+		t.Errorf("Command.Wait() without in-flight got = %v, want nil", err)
+	}
+
+	resCh := make(chan error, 1)
+	go func() {
+		_, err := command.Execute(true)
+		resCh <- err
+	}()
+	<-started
+	command.Drain()
+
+	// Drain 后拒绝新的执行。
+	if _, err := command.Execute(false); !errors.Is(err, ErrClosed) {
+		t.Errorf("Command.Execute() after Drain got = %v, want %v", err, ErrClosed)
+	}
+
+	// 正在执行的没有完成前，Wait 等待到ctx结束。
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	if err := command.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Command.Wait() with in-flight got = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// 正在执行的正常完成，之后 Wait 返回。
+	close(finish)
+	if err := <-resCh; err != nil {
+		t.Errorf("Command.Execute() in-flight got = %v, want nil", err)
+	}
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	if err := command.Wait(ctx2); err != nil {
+		t.Errorf("Command.Wait() after in-flight done got = %v, want nil", err)
+	}
+}
+
+func TestNewCommandE(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return i, nil
+	}
+	// 在选项中创建熔断器，休眠时间错误时panic。
+	badBreaker := func(c *Command) {
+		c.breaker = breaker.NewCutBreaker(c.name, breaker.WithCutBreakerTimeWindow(5*time.Second), breaker.WithCutBreakerSleepWindow(0))
+	}
+
+	tests := []struct {
+		name    string
+		cmdName string
+		run     CommandFunc
+		options []CommandOptionFunc
+		wantErr string // 为空表示没有错误。
+	}{
+		{"valid", "test", run, []CommandOptionFunc{WithCommandTimeout(time.Second)}, ""},
+		{"noTimeout", "test", run, nil, ""},
+		{"emptyName", "", run, nil, "command: invalid config: empty name"},
+		{"nilRun", "test", nil, nil, "test: command: invalid config: nil run function"},
+		{"zeroTimeout", "test", run, []CommandOptionFunc{WithCommandTimeout(0)}, "test: command: invalid config: timeout must be greater than 0"},
+		{"negativeFallbackTimeout", "test", run, []CommandOptionFunc{WithCommandFallbackTimeout(-time.Second)}, "test: command: invalid config: fallback timeout must be greater than 0"},
+		{"badBreaker", "test", run, []CommandOptionFunc{badBreaker}, "test: command: invalid config: breaker: sleepWindow invalid"},
+	}
+	for _, tt := range tests {
+		command, err := NewCommandE(tt.cmdName, tt.run, tt.options...)
+		if tt.wantErr == "" {
+			if err != nil || command == nil {
+				t.Errorf("%s: NewCommandE() got = %v, %v, want command, nil", tt.name, command, err)
+				continue
+			}
+			if res, err := command.Execute(1); err != nil || res != 1 {
+				t.Errorf("%s: Command.Execute() got = %v, %v, want %v, nil", tt.name, res, err, 1)
+			}
+			command.Close()
+			continue
+		}
+		if command != nil || !errors.Is(err, ErrInvalidConfig) || err.Error() != tt.wantErr {
+			t.Errorf("%s: NewCommandE() got = %v, %v, want nil, %v", tt.name, command, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCommand_Name(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return i, nil
+	}
+
+	command := NewCommand("test", run)
+	defer command.Close()
+	if name := command.Name(); name != "test" {
+		t.Errorf("Command.Name() got = %v, want %v", name, "test")
+	}
+
+	// 名称为空时生成不重复的名称，并用于错误信息与熔断器。
+	unnamed1, unnamed2 := NewCommand("", run), NewCommand("", run)
+	defer unnamed1.Close()
+	defer unnamed2.Close()
+	if unnamed1.Name() == "" || unnamed1.Name() == unnamed2.Name() {
+		t.Errorf("Command.Name() with empty name got = %q, %q, want unique non-empty names", unnamed1.Name(), unnamed2.Name())
+	}
+	if name := unnamed1.Summary().Name; name != unnamed1.Name() {
+		t.Errorf("Command.Summary() Name got = %v, want %v", name, unnamed1.Name())
+	}
+	unnamed1.Close()
+	if _, err := unnamed1.Execute(1); err == nil || err.Error() != unnamed1.Name()+": command: closed" {
+		t.Errorf("Command.Execute() got = %v, want %v", err, unnamed1.Name()+": command: closed")
+	}
+}
+
+func TestCommand_Metrics(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return i, nil
+	}
+	command := NewCommand("test", run)
+	defer command.Close()
+
+	view := command.Metrics() // 只获取一次，之后反复读取。
+	for i := int64(1); i <= 3; i++ {
+		command.Execute(1)
+		if summary := view.Summary(); summary.Success != i || summary.Name != "test" {
+			t.Errorf("MetricsView.Summary() got = %s/%d, want %s/%d", summary.Name, summary.Success, "test", i)
+		}
+	}
+}
+
+func TestCommand_recoverPanic(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		panic("run panic")
+	}
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		if i.(bool) {
+			panic("fallback panic")
+		}
+		return "fallback", nil
+	}
+
+	for _, timeout := range []time.Duration{0, time.Second} { // 有无超时包装时都一样。
+		options := []CommandOptionFunc{WithCommandRecoverPanic(true), WithCommandFallback(fallback)}
+		if timeout > 0 {
+			options = append(options, WithCommandTimeout(timeout))
+		}
+		command := NewCommand("test", run, options...)
+
+		// 功能函数panic，记为失败，执行降级函数。
+		if res, err := command.Execute(false); err != nil || res != "fallback" {
+			t.Errorf("timeout %v: Command.Execute() got = %v, %v, want %v, nil", timeout, res, err, "fallback")
+		}
+
+		// 降级函数也panic，记为降级失败，返回 PanicError。
+		_, err := command.Execute(true)
+		var panicErr *PanicError
+		if !errors.Is(err, ErrPanic) || !errors.As(err, &panicErr) || panicErr.Value != "fallback panic" {
+			t.Errorf("timeout %v: Command.Execute() got = %v, want %v", timeout, err, "fallback panic")
+		}
+
+		time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+		if summary := command.Summary(); summary.Failure != 2 || summary.FallbackSuccess != 1 || summary.FallbackFailure != 1 {
+			t.Errorf("timeout %v: Command.Summary() got = %d/%d/%d, want %d/%d/%d", timeout,
+				summary.Failure, summary.FallbackSuccess, summary.FallbackFailure, 2, 1, 1)
+		}
+		command.Close()
+	}
+
+	// 没有降级函数时直接返回功能函数的 PanicError。
+	command := NewCommand("test", run, WithCommandRecoverPanic(true))
+	defer command.Close()
+	if _, err := command.Execute(false); !errors.Is(err, ErrPanic) || err.Error() != "test: command: panic: run panic" {
+		t.Errorf("Command.Execute() got = %v, want %v", err, "test: command: panic: run panic")
+	}
+}
+
+func TestCommand_noopBreaker(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return nil, errors.New("must err")
+	}
+	command := NewCommand("test", run, WithCommandBreaker(breaker.NewNoopBreaker("test")))
+	defer command.Close()
+
+	// 全部失败也不会熔断，每次都执行功能函数。
+	for i := 0; i < 100; i++ {
+		if _, err := command.Execute(1); err == nil || err.Error() != "must err" {
+			t.Fatalf("Command.Execute() got = %v, want %v", err, "must err")
+		}
+	}
+	if summary := command.Summary(); summary.Failure != 100 {
+		t.Errorf("Command.Summary() Failure got = %d, want %d", summary.Failure, 100)
+	}
+}
+
+// TestCommand_slowCallOnlySuccess 测试 Command 只把执行成功的慢调用传给 CutBreaker。
+func TestCommand_slowCallOnlySuccess(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		time.Sleep(20 * time.Millisecond)
+		if i.(bool) {
+			return nil, errors.New("must err")
+		}
+		return "ok", nil
+	}
+	command := NewCommand("test", run, WithCommandBreaker(breaker.NewCutBreaker("test",
+		breaker.WithCutBreakerTimeWindow(5*time.Second),
+		breaker.WithCutBreakerMinRequestThreshold(100),
+		breaker.WithCutBreakerSlowCallDurationThreshold(10*time.Millisecond))))
+	defer command.Close()
+
+	for i := 0; i < 5; i++ {
+		command.Execute(i < 3) // 前3次失败，后2次成功，全部都是慢调用。
+	}
+	if summary := command.Summary(); summary.SlowCall != 2 || summary.SlowCallPercentage != 40 {
+		t.Errorf("Command.Summary() SlowCall got = %d/%v, want %d/%v", summary.SlowCall, summary.SlowCallPercentage, 2, 40)
+	}
+}
+
+func TestCommand_gradientBreaker(t *testing.T) {
+	t.Parallel()
+	releaseCh := make(chan struct{})
+	startedCh := make(chan struct{}, 2)
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if i.(bool) {
+			startedCh <- struct{}{}
+			<-releaseCh
+		}
+		return "ok", nil
+	}
+	command := NewCommand("test", run, WithCommandBreaker(
+		breaker.NewGradientBreaker("test", breaker.WithGradientBreakerInitialLimit(2))))
+	defer command.Close()
+
+	// 执行完成后释放并发数，顺序执行不会被拒绝。
+	for i := 0; i < 10; i++ {
+		if res, err := command.Execute(false); err != nil || res != "ok" {
+			t.Fatalf("Command.Execute() got = %v, %v, want %v, nil", res, err, "ok")
+		}
+	}
+
+	// 两个执行挂起时并发数已满，拒绝其它请求。
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			command.Execute(true)
+			wg.Done()
+		}()
+		<-startedCh
+	}
+	if _, err := command.Execute(false); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Command.Execute() got = %v, want %v", err, ErrCircuitOpen)
+	}
+	close(releaseCh)
+	wg.Wait()
+	if res, err := command.Execute(false); err != nil || res != "ok" {
+		t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "ok")
+	}
+}
+
+// TestCommand_gradientBreakerRejected 测试并发数已满被拒绝的执行会归还 GradientBreaker 的并发数。
+func TestCommand_gradientBreakerRejected(t *testing.T) {
+	t.Parallel()
+	releaseCh := make(chan struct{})
+	startedCh := make(chan struct{})
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if i.(bool) {
+			startedCh <- struct{}{}
+			<-releaseCh
+		}
+		return "ok", nil
+	}
+	command := NewCommand("test", run,
+		WithCommandMaxConcurrency(1),
+		WithCommandBreaker(breaker.NewGradientBreaker("test", breaker.WithGradientBreakerInitialLimit(4))))
+	defer command.Close()
+
+	done := make(chan struct{})
+	go func() {
+		command.Execute(true)
+		close(done)
+	}()
+	<-startedCh
+
+	// 被拒绝的执行没有耗时，依然需要归还并发数，否则很快就会占满熔断器的上限。
+	for i := 0; i < 10; i++ {
+		if _, err := command.Execute(false); !errors.Is(err, ErrMaxConcurrency) {
+			t.Fatalf("Command.Execute() #%d got = %v, want %v", i, err, ErrMaxConcurrency)
+		}
+	}
+	close(releaseCh)
+	<-done
+	if res, err := command.Execute(false); err != nil || res != "ok" {
+		t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "ok")
+	}
+}
+
+// TestCommand_gradientBreakerTimeoutPanic 测试设置了超时时间的功能函数panic后，依然会释放 GradientBreaker 的并发数。
+func TestCommand_gradientBreakerTimeoutPanic(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		panic("must panic")
+	}
+	command := NewCommand("test", run,
+		WithCommandTimeout(time.Second),
+		WithCommandBreaker(breaker.NewGradientBreaker("test", breaker.WithGradientBreakerInitialLimit(4))))
+	defer command.Close()
+
+	for i := 0; i < 10; i++ {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Command.Execute() want panic")
+				}
+			}()
+			command.Execute(nil)
+		}()
+	}
+	if status := command.Summary().Status; !strings.HasPrefix(status, "in-flight = 0,") {
+		t.Errorf("Command.Summary() Status got = %v, want in-flight = 0", status)
+	}
+}
+
+func TestCommand_summaryAfterClose(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if i.(bool) {
+			return nil, errors.New("must err")
+		}
+		return i, nil
+	}
+	command := NewCommand("test", run)
+	for i := 0; i < 100; i++ {
+		command.Execute(i%4 == 0)
+	}
+	command.Close()
+
+	// 关闭后立即获取，所有事件都已经计入。
+	if summary := command.Summary(); summary.Success != 75 || summary.Failure != 25 {
+		t.Errorf("Command.Summary() after Close got = %d/%d, want %d/%d", summary.Success, summary.Failure, 75, 25)
+	}
+}
+
+func TestCommand_executeAfterClose(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return i, nil
+	}
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "fallback", nil
+	}
+	command := NewCommand("test", run, WithCommandTimeout(time.Second), WithCommandFallback(fallback))
+	command.Close()
+
+	executes := map[string]func() error{
+		"Execute": func() error {
+			_, err := command.Execute(1)
+			return err
+		},
+		"ContextExecute": func() error {
+			_, err := command.ContextExecute(context.Background(), 1)
+			return err
+		},
+		"ExecuteWithTimeout": func() error {
+			_, err := command.ExecuteWithTimeout(1, time.Second)
+			return err
+		},
+		"ExecuteWithInfo": func() error {
+			_, _, err := command.ExecuteWithInfo(context.Background(), 1)
+			return err
+		},
+		"ExecuteWithJSON": func() error {
+			_, err := command.ExecuteWithJSON(context.Background(), 1, nil)
+			return err
+		},
+	}
+	for name, execute := range executes {
+		errCh := make(chan error, 1)
+		go func() { errCh <- execute() }()
+		select {
+		case err := <-errCh:
+			if !errors.Is(err, ErrClosed) {
+				t.Errorf("Command.%s() after Close got = %v, want %v", name, err, ErrClosed)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Command.%s() after Close hangs", name)
+		}
+	}
+
+	// 功能函数与降级函数都不会执行，也不会记录统计数据。
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Errorf("run and fallback calls got = %d, want %d", n, 0)
+	}
+	if summary := command.Summary(); summary.Total != 0 {
+		t.Errorf("Command.Summary() Total got = %d, want %d", summary.Total, 0)
+	}
+}
@@ -0,0 +1,48 @@
+package circuit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+// CommandConfig 是Command的有效配置，用于调试或在管理界面中展示。
+type CommandConfig struct {
+	Name string // 名称。
+
+	Timeout          time.Duration // 超时时间，0表示不限制。
+	FallbackTimeout  time.Duration // 降级函数的超时时间，0表示不限制。
+	RetryMaxAttempts int           // 功能函数最多执行的次数（含第一次）。
+	Idempotent       bool          // 功能函数是否幂等。
+	MaxConcurrency   int           // 最大并发执行数量，0表示不限制。
+	MaxStuckInFlight int64         // 允许超时后仍未返回的最大执行数量，0表示不限制。
+	RecoverPanic     bool          // 是否将panic转换为错误。
+
+	HasFallback   bool // 是否设置了降级函数。
+	HasLastResort bool // 是否设置了最后处理函数。
+
+	Breaker breaker.BreakerConfig // 熔断器配置，熔断器没有实现 breaker.Configurable 时只有Go类型名称。
+}
+
+// Config 返回Command的有效配置。
+func (command *Command) Config() CommandConfig {
+	config := CommandConfig{
+		Name:             command.name,
+		Timeout:          command.getTimeout(ExecOptions{}),
+		FallbackTimeout:  command.getFallbackTimeout(command.getTimeout(ExecOptions{})),
+		RetryMaxAttempts: command.retryMaxAttempts,
+		Idempotent:       command.idempotent,
+		MaxConcurrency:   cap(command.semaphore),
+		MaxStuckInFlight: command.maxStuckInFlight,
+		RecoverPanic:     command.recoverPanic,
+		HasFallback:      command.fallback != nil,
+		HasLastResort:    command.lastResort != nil,
+	}
+	if configurable, ok := command.breaker.(breaker.Configurable); ok {
+		config.Breaker = configurable.Config()
+	} else {
+		config.Breaker = breaker.BreakerConfig{Type: fmt.Sprintf("%T", command.breaker)}
+	}
+	return config
+}
@@ -0,0 +1,59 @@
+package circuit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+func TestCommand_Config(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return i, nil
+	}
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		return nil, e
+	}
+
+	command := NewCommand("test", run,
+		WithCommandTimeout(time.Second),
+		WithCommandFallback(fallback),
+		WithCommandRetry(3, nil),
+		WithCommandMaxConcurrency(10),
+		WithCommandBreaker(breaker.NewSreBreaker("test", breaker.WithSreBreakerK(1.5))))
+	defer command.Close()
+
+	got := command.Config()
+	want := CommandConfig{
+		Name:             "test",
+		Timeout:          time.Second,
+		FallbackTimeout:  time.Second,
+		RetryMaxAttempts: 3,
+		Idempotent:       true,
+		MaxConcurrency:   10,
+		HasFallback:      true,
+		Breaker: breaker.BreakerConfig{
+			Type: "sre",
+			Options: map[string]interface{}{
+				"timeWindow":       time.Minute * 2,
+				"k":                1.5,
+				"timeoutWeight":    float64(1),
+				"minRequests":      int64(0),
+				"fallbackAsAccept": false,
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Command.Config() got = %+v, want %+v", got, want)
+	}
+
+	// 默认熔断器。
+	command = NewCommand("default", run)
+	defer command.Close()
+	if config := command.Config(); config.Breaker.Type != "cut" || config.Breaker.Options["errorThresholdPercentage"] != float64(50) || config.HasFallback {
+		t.Errorf("Command.Config() got = %+v", config)
+	}
+}
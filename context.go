@@ -0,0 +1,12 @@
+package circuit
+
+import "context"
+
+// commandNameKey 是context中保存Command名称的key。
+type commandNameKey struct{}
+
+// CommandNameFromContext 用于在功能函数/降级函数中获取当前正在执行的Command名称。
+func CommandNameFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(commandNameKey{}).(string)
+	return name, ok
+}
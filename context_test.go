@@ -0,0 +1,44 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCommandNameFromContext(t *testing.T) {
+	t.Parallel()
+	// 功能函数返回context中的名称，param为true时返回错误以进入降级函数。
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if i.(bool) {
+			return nil, errors.New("must err")
+		}
+		name, _ := CommandNameFromContext(ctx)
+		return name, nil
+	}
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		name, _ := CommandNameFromContext(ctx)
+		return name, nil
+	}
+
+	for _, timeout := range []time.Duration{0, time.Second} { // 有无超时包装时都应该生效。
+		options := []CommandOptionFunc{WithCommandFallback(fallback)}
+		if timeout > 0 {
+			options = append(options, WithCommandTimeout(timeout))
+		}
+		command := NewCommand("test", run, options...)
+
+		if res, err := command.Execute(false); err != nil || res != "test" {
+			t.Errorf("Command.Execute() run got = %v, %v, want %v, nil", res, err, "test")
+		}
+		if res, err := command.Execute(true); err != nil || res != "test" {
+			t.Errorf("Command.Execute() fallback got = %v, %v, want %v, nil", res, err, "test")
+		}
+		command.Close()
+	}
+
+	if _, ok := CommandNameFromContext(context.Background()); ok {
+		t.Errorf("CommandNameFromContext() got = %v, want %v", ok, false)
+	}
+}
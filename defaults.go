@@ -0,0 +1,53 @@
+package circuit
+
+import (
+	"sync/atomic"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+// defaultOptions 保存全局默认的Command选项函数，类型为 optionsHolder。
+var defaultOptions atomic.Value
+
+// optionsHolder 用于在 atomic.Value 中保存可能为空的选项函数列表。
+type optionsHolder struct {
+	options []CommandOptionFunc
+}
+
+// defaultBreakerFactory 保存全局默认的熔断器创建函数，类型为 breakerFactoryHolder。
+var defaultBreakerFactory atomic.Value
+
+// breakerFactoryHolder 用于在 atomic.Value 中保存可能为nil的熔断器创建函数。
+type breakerFactoryHolder struct {
+	factory func(name string) breaker.Breaker
+}
+
+// SetDefaultCommandOptions 用于设置全局默认的Command选项函数，不传入任何选项可以取消。
+// NewCommand 会先应用默认选项，再应用创建时传入的选项，所以创建时传入的选项优先；只对之后创建的Command生效，可以并发设置。
+// 熔断器不能在多个Command之间共享，默认选项中请不要使用 WithCommandBreaker，改为通过 SetDefaultBreakerFactory 设置。
+func SetDefaultCommandOptions(options ...CommandOptionFunc) {
+	defaultOptions.Store(optionsHolder{append([]CommandOptionFunc(nil), options...)})
+}
+
+// SetDefaultBreakerFactory 用于设置全局默认的熔断器创建函数，传入nil可以取消。
+// 创建Command时没有通过选项函数指定熔断器的，使用该函数以Command名称创建熔断器，没有设置时使用默认参数的 CutBreaker。
+// 只对之后创建的Command生效，可以并发设置。
+func SetDefaultBreakerFactory(factory func(name string) breaker.Breaker) {
+	defaultBreakerFactory.Store(breakerFactoryHolder{factory})
+}
+
+// getDefaultOptions 返回全局默认的Command选项函数。
+func getDefaultOptions() []CommandOptionFunc {
+	if holder, ok := defaultOptions.Load().(optionsHolder); ok {
+		return holder.options
+	}
+	return nil
+}
+
+// getDefaultBreakerFactory 返回全局默认的熔断器创建函数，没有设置时返回nil。
+func getDefaultBreakerFactory() func(name string) breaker.Breaker {
+	if holder, ok := defaultBreakerFactory.Load().(breakerFactoryHolder); ok {
+		return holder.factory
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+// TestSetDefaultCommandOptions 修改了全局的默认选项，不能与其它测试并行。
+func TestSetDefaultCommandOptions(t *testing.T) {
+	SetDefaultCommandOptions(WithCommandTimeout(time.Millisecond * 20))
+	defer SetDefaultCommandOptions()
+
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		time.Sleep(time.Millisecond * 50)
+		return "ok", nil
+	}
+
+	// 使用全局默认的超时时间。
+	command := NewCommand("default", run)
+	defer command.Close()
+	if timeout := command.Config().Timeout; timeout != time.Millisecond*20 {
+		t.Errorf("Command.Config() Timeout got = %v, want %v", timeout, time.Millisecond*20)
+	}
+	if _, err := command.Execute(1); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Command.Execute() got = %v, want %v", err, ErrTimeout)
+	}
+
+	// 创建时传入的选项优先。
+	overridden := NewCommand("overridden", run, WithCommandTimeout(time.Second))
+	defer overridden.Close()
+	if res, err := overridden.Execute(1); err != nil || res != "ok" {
+		t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "ok")
+	}
+
+	// 取消后不再生效。
+	SetDefaultCommandOptions()
+	cancelled := NewCommand("cancelled", run)
+	defer cancelled.Close()
+	if timeout := cancelled.Config().Timeout; timeout != 0 {
+		t.Errorf("Command.Config() Timeout got = %v, want %v", timeout, 0)
+	}
+}
+
+// TestSetDefaultBreakerFactory 修改了全局的熔断器创建函数，不能与其它测试并行。
+func TestSetDefaultBreakerFactory(t *testing.T) {
+	var lock sync.Mutex
+	var names []string
+	SetDefaultBreakerFactory(func(name string) breaker.Breaker {
+		lock.Lock()
+		defer lock.Unlock()
+		names = append(names, name)
+		return breaker.NewNoopBreaker(name)
+	})
+	defer SetDefaultBreakerFactory(nil)
+
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return i, nil
+	}
+
+	command := NewCommand("factory", run)
+	defer command.Close()
+	if typ := command.Config().Breaker.Type; typ != "noop" {
+		t.Errorf("Command.Config() Breaker.Type got = %v, want %v", typ, "noop")
+	}
+
+	// 通过选项函数指定了熔断器时不使用创建函数。
+	own := NewCommand("own", run, WithCommandBreaker(breaker.NewSreBreaker("own")))
+	defer own.Close()
+	if typ := own.Config().Breaker.Type; typ != "sre" {
+		t.Errorf("Command.Config() Breaker.Type got = %v, want %v", typ, "sre")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(names) != 1 || names[0] != "factory" {
+		t.Errorf("breaker factory calls got = %v, want %v", names, []string{"factory"})
+	}
+}
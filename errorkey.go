@@ -0,0 +1,69 @@
+package circuit
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+const (
+	topErrorKeyCount = 5    // 统计摘要中展示的错误特征数量。
+	maxErrorKeyCount = 1000 // 最多记录的错误特征数量，超过后新的特征统一计入otherErrorKey，以免内存无限增长。
+)
+
+const otherErrorKey = "other" // 超过记录数量上限后，新的错误特征统一使用的特征。
+
+// errorKeyCounter 用于按错误特征统计功能函数的失败次数，以便发现集中出现的错误（如某个下游节点故障）。
+type errorKeyCounter struct {
+	keyFunc func(error) string // 从错误中提取特征的函数。
+
+	lock   sync.Mutex
+	counts map[string]int64 // 每种错误特征的出现次数。
+}
+
+// newErrorKeyCounter 用于新建一个错误特征计数器。
+func newErrorKeyCounter(keyFunc func(error) string) *errorKeyCounter {
+	return &errorKeyCounter{
+		keyFunc: keyFunc,
+		counts:  make(map[string]int64),
+	}
+}
+
+// record 用于记录一次错误。
+func (c *errorKeyCounter) record(err error) {
+	key := c.keyFunc(err)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.counts[key]; !ok && len(c.counts) >= maxErrorKeyCount {
+		key = otherErrorKey
+	}
+	c.counts[key]++
+}
+
+// top 返回出现次数最多的n个错误特征，reset为true时同时清空统计。
+func (c *errorKeyCounter) top(n int, reset bool) []breaker.ErrorKeyCount {
+	c.lock.Lock()
+	counts := c.counts
+	if reset {
+		c.counts = make(map[string]int64)
+	}
+	result := make([]breaker.ErrorKeyCount, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, breaker.ErrorKeyCount{Key: key, Count: count})
+	}
+	c.lock.Unlock()
+
+	// 按次数降序，次数相同时按特征排序，保证结果稳定。
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+func TestCommand_errorKey(t *testing.T) {
+	t.Parallel()
+	// 功能函数，参数为下游节点id，返回包含节点id的错误。
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("node-%d: connection refused", i.(int))
+	}
+	// 错误特征函数，提取错误中的节点id。
+	keyFunc := func(err error) string {
+		return strings.SplitN(err.Error(), ":", 2)[0]
+	}
+	// 调高最小流量，以免中途熔断。
+	command := NewCommand("test", run,
+		WithCommandBreaker(breaker.NewCutBreaker("test",
+			breaker.WithCutBreakerTimeWindow(5*time.Second),
+			breaker.WithCutBreakerMinRequestThreshold(1000))),
+		WithCommandErrorKeyFunc(keyFunc))
+	defer command.Close()
+
+	// node-3占绝大多数。
+	for i := 0; i < 10; i++ {
+		command.Execute(i % 5)
+		command.Execute(3)
+	}
+
+	summary := command.Summary()
+	if len(summary.TopErrorKeys) == 0 {
+		t.Fatalf("Command.Summary() TopErrorKeys got = %v, want not empty", summary.TopErrorKeys)
+	}
+	if top := summary.TopErrorKeys[0]; top.Key != "node-3" || top.Count != 12 {
+		t.Errorf("Command.Summary() TopErrorKeys[0] got = %+v, want {node-3 12}", top)
+	}
+	if len(summary.TopErrorKeys) != topErrorKeyCount {
+		t.Errorf("Command.Summary() len(TopErrorKeys) got = %d, want %d", len(summary.TopErrorKeys), topErrorKeyCount)
+	}
+
+	// Drain后清空。
+	command.DrainStats()
+	if summary := command.Summary(); len(summary.TopErrorKeys) != 0 {
+		t.Errorf("Command.Summary() after DrainStats TopErrorKeys got = %v, want empty", summary.TopErrorKeys)
+	}
+}
+
+func TestErrorKeyCounter_limit(t *testing.T) {
+	t.Parallel()
+	counter := newErrorKeyCounter(func(err error) string { return err.Error() })
+	for i := 0; i < maxErrorKeyCount+10; i++ {
+		counter.record(errors.New(fmt.Sprint(i)))
+	}
+	counter.record(errors.New("0")) // 已存在的特征依然正常计数。
+
+	top := counter.top(2, false)
+	if top[0].Key != otherErrorKey || top[0].Count != 10 {
+		t.Errorf("errorKeyCounter.top()[0] got = %+v, want {%s 10}", top[0], otherErrorKey)
+	}
+	if top[1].Key != "0" || top[1].Count != 2 {
+		t.Errorf("errorKeyCounter.top()[1] got = %+v, want {0 2}", top[1])
+	}
+}
@@ -0,0 +1,104 @@
+package circuit
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+// EventType 表示执行事件的类型，与熔断器记录的结果一一对应。
+type EventType string
+
+const (
+	EventSuccess         EventType = "success"          // 熔断器记为成功（包括不计为失败的错误）。
+	EventFailure         EventType = "failure"          // 熔断器记为失败。
+	EventTimeout         EventType = "timeout"          // 熔断器记为超时。
+	EventRejected        EventType = "rejected"         // 没有执行功能函数就被拒绝（熔断器开启、并发数量已满、挂起过多）。
+	EventIgnored         EventType = "ignored"          // 功能函数标记了不计入统计。
+	EventFallbackSuccess EventType = "fallback-success" // 降级函数执行成功。
+	EventFallbackFailure EventType = "fallback-failure" // 降级函数执行失败。
+	EventStateChange     EventType = "state-change"     // 熔断器状态发生变化，只发布到 Command.Events 返回的channel。
+)
+
+// Event 记录一次执行事件。
+type Event struct {
+	Name string    // Command名称。
+	Type EventType // 事件类型。
+	Err  error     // 本次事件相关的错误，成功时为nil。
+	Time time.Time // 事件发生的时间。
+
+	State breaker.State // 变化后的熔断器状态，只有 EventStateChange 有效。
+}
+
+// eventsBufferSize 是 Command.Events 返回的channel的缓冲大小。
+const eventsBufferSize = 100
+
+// Events 返回一个订阅Command执行事件的channel，多次调用返回同一个channel，便于接入审计日志、事件总线等。
+// 除了执行结果外，还会发布熔断器状态变化的 EventStateChange 事件：熔断器实现了 breaker.StateNotifier（如 CutBreaker）时，
+// 在熔断器切换状态后异步发布，与执行事件之间不保证先后顺序；否则订阅后每次执行会额外读取熔断器状态，通过比较执行前后的状态发布。
+// 事件以非阻塞的方式发布，channel已满时直接丢弃，消费过慢不会阻塞执行；与 WithCommandEventChannel 相互独立。
+func (command *Command) Events() <-chan Event {
+	command.eventsOnce.Do(func() {
+		command.events.Store(make(chan Event, eventsBufferSize))
+		if command.stateNotified {
+			command.watchState()
+		}
+	})
+	return command.events.Load().(chan Event)
+}
+
+// subscribed 返回是否已经通过 Events 订阅了事件。
+func (command *Command) subscribed() bool {
+	_, ok := command.events.Load().(chan Event)
+	return ok
+}
+
+// publish 用于将事件发布到 Events 返回的channel中，没有订阅时直接忽略，channel已满时丢弃。
+func (command *Command) publish(event Event) {
+	ch, ok := command.events.Load().(chan Event)
+	if !ok {
+		return
+	}
+	select {
+	case ch <- event:
+	default: // channel已满，丢弃事件，以免阻塞执行。
+	}
+}
+
+// defaultObserver 保存全局默认的事件观察函数，类型为 observerHolder。
+var defaultObserver atomic.Value
+
+// observerHolder 用于在 atomic.Value 中保存可能为nil的观察函数。
+type observerHolder struct {
+	observe func(Event)
+}
+
+// SetDefaultObserver 用于设置全局默认的事件观察函数，所有没有设置事件channel的Command都会将事件交给该函数，传入nil可以取消。
+// 可以并发设置，设置后对已经创建的Command同样生效；观察函数在执行的goroutine中同步调用，请尽快返回。
+func SetDefaultObserver(observe func(Event)) {
+	defaultObserver.Store(observerHolder{observe})
+}
+
+// emit 用于将事件发送到设置的channel中，没有设置时交给全局默认的观察函数，都没有设置时直接忽略。
+// 通过 Events 订阅了事件时，同时发布到订阅的channel中。
+func (command *Command) emit(eventType EventType, err error) {
+	if command.subscribed() {
+		command.publish(Event{Name: command.name, Type: eventType, Err: err, Time: time.Now()})
+	}
+	if command.eventCh == nil {
+		if holder, ok := defaultObserver.Load().(observerHolder); ok && holder.observe != nil {
+			holder.observe(Event{Name: command.name, Type: eventType, Err: err, Time: time.Now()})
+		}
+		return
+	}
+	event := Event{Name: command.name, Type: eventType, Err: err, Time: time.Now()}
+	if !command.eventDropIfFull {
+		command.eventCh <- event
+		return
+	}
+	select {
+	case command.eventCh <- event:
+	default: // channel已满，丢弃事件，以免阻塞执行。
+	}
+}
@@ -0,0 +1,237 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+func TestCommand_eventChannel(t *testing.T) {
+	t.Parallel()
+	// 功能函数，按param决定执行结果。
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		switch i.(string) {
+		case "failure":
+			return nil, errors.New("must err")
+		case "timeout":
+			time.Sleep(time.Millisecond * 100)
+		case "ignore":
+			return nil, MarkIgnore(errors.New("ignore"))
+		}
+		return "ok", nil
+	}
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		if errors.Is(e, ErrTimeout) {
+			return nil, e // 超时的降级函数返回失败。
+		}
+		return "fallback", nil
+	}
+
+	t.Run("every outcome", func(t *testing.T) {
+		eventCh := make(chan Event, 100)
+		command := NewCommand("test", run,
+			WithCommandTimeout(time.Millisecond*20),
+			WithCommandFallback(fallback),
+			WithCommandEventChannel(eventCh, false))
+		defer command.Close()
+
+		for _, param := range []string{"success", "failure", "timeout", "ignore"} {
+			command.Execute(param)
+		}
+		command.breaker.ForceOpen()
+		command.Execute("success")
+
+		want := []EventType{
+			EventSuccess,
+			EventFailure, EventFallbackSuccess,
+			EventTimeout, EventFallbackFailure,
+			EventIgnored,
+			EventRejected, EventFallbackSuccess,
+		}
+		if len(eventCh) != len(want) {
+			t.Fatalf("events len got = %d, want %d", len(eventCh), len(want))
+		}
+		for i, eventType := range want {
+			event := <-eventCh
+			if event.Name != "test" || event.Type != eventType {
+				t.Errorf("event %d got = %v/%v, want %v/%v", i, event.Name, event.Type, "test", eventType)
+			}
+		}
+	})
+
+	t.Run("drop if full", func(t *testing.T) {
+		eventCh := make(chan Event, 1)
+		command := NewCommand("test", run, WithCommandEventChannel(eventCh, true))
+		defer command.Close()
+
+		// channel已满后不会阻塞执行，多出的事件被丢弃。
+		for i := 0; i < 3; i++ {
+			if res, err := command.Execute("success"); err != nil || res != "ok" {
+				t.Errorf("Command.Execute() got = %v, %v, want %v, nil", res, err, "ok")
+			}
+		}
+		if len(eventCh) != 1 {
+			t.Errorf("events len got = %d, want %d", len(eventCh), 1)
+		}
+	})
+}
+
+func TestCommand_Events(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if i.(bool) {
+			return nil, errors.New("must err")
+		}
+		return "ok", nil
+	}
+	command := NewCommand("test", run,
+		WithCommandBreaker(breaker.NewCutBreaker("test",
+			breaker.WithCutBreakerTimeWindow(5*time.Second),
+			breaker.WithCutBreakerErrorThresholdPercentage(50),
+			breaker.WithCutBreakerMinRequestThreshold(2),
+			breaker.WithCutBreakerSleepWindow(50*time.Millisecond))))
+	defer command.Close()
+
+	events := command.Events()
+	if command.Events() != events {
+		t.Errorf("Command.Events() got different channels, want the same")
+	}
+
+	// 完整的熔断周期：失败→开启→拒绝→半开→成功→关闭。
+	command.Execute(true)
+	command.Execute(true)
+	command.Execute(false) // 熔断器开启，被拒绝。
+	time.Sleep(60 * time.Millisecond)
+	command.Execute(false) // 半开探测成功，熔断器关闭。
+
+	// 状态变化事件由熔断器异步通知，与执行事件之间不保证先后顺序，分别检查。
+	wantExec := []EventType{EventFailure, EventFailure, EventRejected, EventSuccess}
+	wantStates := []breaker.State{breaker.StateOpen, breaker.StateHalfOpen, breaker.StateClosed}
+	var gotExec []EventType
+	var gotStates []breaker.State
+	for len(gotExec)+len(gotStates) < len(wantExec)+len(wantStates) {
+		select {
+		case event := <-events:
+			if event.Name != "test" {
+				t.Errorf("event Name got = %v, want %v", event.Name, "test")
+			}
+			if event.Type == EventStateChange {
+				gotStates = append(gotStates, event.State)
+			} else {
+				gotExec = append(gotExec, event.Type)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("events got = %v/%v, want %v/%v", gotExec, gotStates, wantExec, wantStates)
+		}
+	}
+	if !reflect.DeepEqual(gotExec, wantExec) {
+		t.Errorf("execution events got = %v, want %v", gotExec, wantExec)
+	}
+	if !reflect.DeepEqual(gotStates, wantStates) {
+		t.Errorf("state-change events got = %v, want %v", gotStates, wantStates)
+	}
+
+	// 消费过慢时丢弃事件，不会阻塞执行。
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < eventsBufferSize*2; i++ {
+			command.Execute(false)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Command.Execute() blocked by a full events channel")
+	}
+	if len(events) != eventsBufferSize {
+		t.Errorf("events len got = %d, want %d", len(events), eventsBufferSize)
+	}
+}
+
+// TestCommand_EventsConcurrent 测试并发执行时，每次熔断器状态变化只发布一次 EventStateChange 事件。
+func TestCommand_EventsConcurrent(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return nil, errors.New("must err")
+	}
+	command := NewCommand("test", run,
+		WithCommandBreaker(breaker.NewCutBreaker("test",
+			breaker.WithCutBreakerTimeWindow(5*time.Second),
+			breaker.WithCutBreakerMinRequestThreshold(10),
+			breaker.WithCutBreakerSleepWindow(time.Hour))))
+	defer command.Close()
+	events := command.Events()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ { // 执行事件的总数不超过channel的缓冲大小。
+				command.Execute(nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var states []breaker.State
+	for timeout := time.After(100 * time.Millisecond); ; {
+		select {
+		case event := <-events:
+			if event.Type == EventStateChange {
+				states = append(states, event.State)
+			}
+			continue
+		case <-timeout:
+		}
+		break
+	}
+	if want := []breaker.State{breaker.StateOpen}; !reflect.DeepEqual(states, want) {
+		t.Errorf("state-change events got = %v, want %v", states, want)
+	}
+}
+
+// TestSetDefaultObserver 修改了全局的观察函数，不能与其它测试并行。
+func TestSetDefaultObserver(t *testing.T) {
+	var lock sync.Mutex
+	counts := make(map[string]int)
+	SetDefaultObserver(func(event Event) {
+		lock.Lock()
+		defer lock.Unlock()
+		counts[event.Name]++
+	})
+	defer SetDefaultObserver(nil)
+
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return i, nil
+	}
+	user := NewCommand("user", run)
+	defer user.Close()
+	order := NewCommand("order", run)
+	defer order.Close()
+
+	// 设置了事件channel的Command不会通知默认的观察函数。
+	eventCh := make(chan Event, 10)
+	own := NewCommand("own", run, WithCommandEventChannel(eventCh, true))
+	defer own.Close()
+
+	user.Execute(1)
+	order.Execute(1)
+	order.Execute(2)
+	own.Execute(1)
+
+	lock.Lock()
+	defer lock.Unlock()
+	if counts["user"] != 1 || counts["order"] != 2 || counts["own"] != 0 {
+		t.Errorf("default observer counts got = %v, want user:1 order:2 own:0", counts)
+	}
+	if len(eventCh) != 1 {
+		t.Errorf("len(eventCh) got = %d, want %d", len(eventCh), 1)
+	}
+}
@@ -38,7 +38,7 @@ func main() {
 		circuit.WithCommandFallback(fallback),
 		circuit.WithCommandTimeout(time.Second*5))
 
-	defer command.Close() // 主要用于释放command中开启的统计goroutine。
+	defer command.Close() // 用于释放command内部资源。
 
 	var wg sync.WaitGroup
 
@@ -0,0 +1,48 @@
+package circuit
+
+import (
+	"context"
+	"time"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+// ExecInfo 记录一次执行的过程信息，用于调试或自适应的调用方。
+type ExecInfo struct {
+	Rejected     bool          // 是否被拒绝执行功能函数（熔断开启、并发已满或挂起过多）。
+	UsedFallback bool          // 是否执行了降级函数。
+	TimedOut     bool          // 功能函数是否超时。
+	State        breaker.State // 执行结束时熔断器的状态。
+	Duration     time.Duration // 本次执行的总耗时，包括降级函数。
+}
+
+// ExecuteWithInfo 用于执行目标函数，并同时返回本次执行的过程信息。
+func (command *Command) ExecuteWithInfo(ctx context.Context, param interface{}) (interface{}, ExecInfo, error) {
+	var info ExecInfo
+	startTime := time.Now()
+	result, err := command.contextExecute(ctx, param, ExecOptions{}, &info)
+	info.State = command.breaker.State()
+	info.Duration = time.Since(startTime)
+	return result, info, err
+}
+
+// markRejected 记录本次执行被拒绝，info为nil时不做任何事。
+func (info *ExecInfo) markRejected() {
+	if info != nil {
+		info.Rejected = true
+	}
+}
+
+// markFallback 记录本次执行使用了降级函数，info为nil时不做任何事。
+func (info *ExecInfo) markFallback() {
+	if info != nil {
+		info.UsedFallback = true
+	}
+}
+
+// markTimedOut 记录本次执行的功能函数超时，info为nil时不做任何事。
+func (info *ExecInfo) markTimedOut() {
+	if info != nil {
+		info.TimedOut = true
+	}
+}
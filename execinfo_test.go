@@ -0,0 +1,45 @@
+package circuit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+func TestCommand_ExecuteWithInfo(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if i.(bool) {
+			time.Sleep(time.Millisecond * 50)
+		}
+		return "run", nil
+	}
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		return "fallback", nil
+	}
+	command := NewCommand("test", run,
+		WithCommandTimeout(time.Millisecond*20),
+		WithCommandFallback(fallback))
+	defer command.Close()
+
+	// 正常执行。
+	res, info, err := command.ExecuteWithInfo(context.Background(), false)
+	if err != nil || res != "run" || info.Rejected || info.UsedFallback || info.TimedOut || info.State != breaker.StateClosed {
+		t.Errorf("Command.ExecuteWithInfo() got = %v, %+v, %v", res, info, err)
+	}
+
+	// 超时后执行降级函数。
+	res, info, err = command.ExecuteWithInfo(context.Background(), true)
+	if err != nil || res != "fallback" || info.Rejected || !info.UsedFallback || !info.TimedOut || info.Duration < time.Millisecond*20 {
+		t.Errorf("Command.ExecuteWithInfo() got = %v, %+v, %v", res, info, err)
+	}
+
+	// 熔断开启时直接执行降级函数。
+	command.breaker.ForceOpen()
+	res, info, err = command.ExecuteWithInfo(context.Background(), false)
+	if err != nil || res != "fallback" || !info.Rejected || !info.UsedFallback || info.TimedOut || info.State != breaker.StateForced {
+		t.Errorf("Command.ExecuteWithInfo() got = %v, %+v, %v", res, info, err)
+	}
+}
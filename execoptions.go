@@ -0,0 +1,70 @@
+package circuit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ExecOptions 是单次执行的可选项，用于覆盖Command的默认配置。
+// 支持JSON序列化，以便通过RPC等方式下发，格式如：{"timeout":"500ms","disableFallback":true}。
+type ExecOptions struct {
+	Timeout         time.Duration // 本次执行的超时时间，为0时使用Command的默认超时时间。
+	DisableFallback bool          // 本次执行是否禁用降级函数。
+}
+
+// execOptionsJSON 是 ExecOptions 的JSON表示，超时时间使用 time.Duration 的字符串格式。
+type execOptionsJSON struct {
+	Timeout         string `json:"timeout,omitempty"`
+	DisableFallback bool   `json:"disableFallback,omitempty"`
+}
+
+// MarshalJSON 实现 json.Marshaler 接口。
+func (opts ExecOptions) MarshalJSON() ([]byte, error) {
+	v := execOptionsJSON{DisableFallback: opts.DisableFallback}
+	if opts.Timeout > 0 {
+		v.Timeout = opts.Timeout.String()
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler 接口，遇到未知字段或非法的超时时间将返回错误。
+func (opts *ExecOptions) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var v execOptionsJSON
+	if err := decoder.Decode(&v); err != nil {
+		return err
+	}
+
+	var timeout time.Duration
+	if v.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(v.Timeout); err != nil {
+			return err
+		}
+		if timeout < 0 {
+			return errors.New("timeout must not be negative")
+		}
+	}
+
+	opts.Timeout = timeout
+	opts.DisableFallback = v.DisableFallback
+	return nil
+}
+
+// ExecuteWithJSON 用于按JSON格式的单次执行可选项执行目标函数。
+// optsJSON 为空时按Command的默认配置执行，解析失败时不会执行目标函数，直接返回错误。
+func (command *Command) ExecuteWithJSON(ctx context.Context, param interface{}, optsJSON []byte) (interface{}, error) {
+	var opts ExecOptions
+	if len(optsJSON) > 0 {
+		if err := json.Unmarshal(optsJSON, &opts); err != nil {
+			return nil, fmt.Errorf("%s: invalid exec options: %w", command.name, err)
+		}
+	}
+	return command.contextExecute(ctx, param, opts, nil)
+}
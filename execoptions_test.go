@@ -0,0 +1,85 @@
+package circuit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExecOptions_json(t *testing.T) {
+	t.Parallel()
+	opts := ExecOptions{Timeout: time.Millisecond * 500, DisableFallback: true}
+	data, err := json.Marshal(opts)
+	if err != nil {
+		t.Fatalf("json.Marshal() got = %v, want nil", err)
+	}
+	if string(data) != `{"timeout":"500ms","disableFallback":true}` {
+		t.Errorf("json.Marshal() got = %s, want %s", data, `{"timeout":"500ms","disableFallback":true}`)
+	}
+
+	var got ExecOptions
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() got = %v, want nil", err)
+	}
+	if got != opts {
+		t.Errorf("json.Unmarshal() got = %+v, want %+v", got, opts)
+	}
+
+	// 非法输入。
+	for _, data := range []string{
+		`{"timeout":"500ms","unknown":1}`,
+		`{"timeout":"abc"}`,
+		`{"timeout":"-1s"}`,
+		`{"disableFallback":"yes"}`,
+	} {
+		if err := json.Unmarshal([]byte(data), &got); err == nil {
+			t.Errorf("json.Unmarshal(%s) got = nil, want error", data)
+		}
+	}
+}
+
+func TestCommand_ExecuteWithJSON(t *testing.T) {
+	t.Parallel()
+	// 功能函数，参数为执行的毫秒数，负数时返回错误。
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if i.(int) < 0 {
+			return nil, errors.New("must err")
+		}
+		time.Sleep(time.Millisecond * time.Duration(i.(int)))
+		return "ok", nil
+	}
+	// 降级函数。
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		return "fallback", nil
+	}
+	command := NewCommand("test", run,
+		WithCommandFallback(fallback),
+		WithCommandTimeout(time.Second*2))
+	defer command.Close()
+
+	// 默认配置下不会超时。
+	if res, err := command.ExecuteWithJSON(context.Background(), 300, nil); err != nil || res != "ok" {
+		t.Errorf("Command.ExecuteWithJSON() got = %v, %v, want %v, nil", res, err, "ok")
+	}
+
+	// 通过JSON覆盖超时时间，并禁用降级函数。
+	optsJSON, _ := json.Marshal(ExecOptions{Timeout: time.Millisecond * 100, DisableFallback: true})
+	if _, err := command.ExecuteWithJSON(context.Background(), 300, optsJSON); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Command.ExecuteWithJSON() got = %v, want %v", err, ErrTimeout)
+	}
+
+	// 只禁用降级函数。
+	if _, err := command.ExecuteWithJSON(context.Background(), -1, []byte(`{"disableFallback":true}`)); err == nil || err.Error() != "must err" {
+		t.Errorf("Command.ExecuteWithJSON() got = %v, want %v", err, "must err")
+	}
+	if res, err := command.ExecuteWithJSON(context.Background(), -1, nil); err != nil || res != "fallback" {
+		t.Errorf("Command.ExecuteWithJSON() got = %v, %v, want %v, nil", res, err, "fallback")
+	}
+
+	// 非法的可选项不会执行目标函数。
+	if _, err := command.ExecuteWithJSON(context.Background(), 0, []byte(`{"retry":3}`)); err == nil {
+		t.Errorf("Command.ExecuteWithJSON() got = nil, want error")
+	}
+}
@@ -0,0 +1,31 @@
+package circuit
+
+import "context"
+
+// FailoverCommand 是由主、备两个Command组成的组合命令，用于多区域容灾等场景。
+// 主、备Command各自维护独立的熔断器状态。
+type FailoverCommand struct {
+	primary   *Command // 主Command。
+	secondary *Command // 备Command。
+}
+
+// Failover 用于新建一个 FailoverCommand，执行时先尝试主Command，主Command返回错误（包括熔断开启）时再尝试备Command。
+func Failover(primary, secondary *Command) *FailoverCommand {
+	return &FailoverCommand{
+		primary:   primary,
+		secondary: secondary,
+	}
+}
+
+// Execute 用于直接执行目标函数。
+func (command *FailoverCommand) Execute(param interface{}) (interface{}, error) {
+	return command.ContextExecute(context.Background(), param)
+}
+
+// ContextExecute 用于执行目标函数，主Command返回错误时转到备Command执行，并返回备Command的结果。
+func (command *FailoverCommand) ContextExecute(ctx context.Context, param interface{}) (interface{}, error) {
+	if result, err := command.primary.ContextExecute(ctx, param); err == nil {
+		return result, nil
+	}
+	return command.secondary.ContextExecute(ctx, param)
+}
@@ -0,0 +1,51 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFailover(t *testing.T) {
+	t.Parallel()
+	newRun := func(region string) CommandFunc {
+		return func(ctx context.Context, i interface{}) (interface{}, error) {
+			if i.(bool) {
+				return nil, errors.New("must err")
+			}
+			return region, nil
+		}
+	}
+	primary := NewCommand("primary", newRun("primary"))
+	defer primary.Close()
+	secondary := NewCommand("secondary", newRun("secondary"))
+	defer secondary.Close()
+	command := Failover(primary, secondary)
+
+	// 主Command正常时不会用到备Command。
+	if res, err := command.Execute(false); err != nil || res != "primary" {
+		t.Errorf("FailoverCommand.Execute() got = %v, %v, want %v, nil", res, err, "primary")
+	}
+
+	// 主Command熔断开启，由备Command提供服务。
+	primary.breaker.ForceOpen()
+	if res, err := command.Execute(false); err != nil || res != "secondary" {
+		t.Errorf("FailoverCommand.Execute() got = %v, %v, want %v, nil", res, err, "secondary")
+	}
+
+	// 都失败时返回备Command的错误。
+	primary.breaker.ClearForced()
+	if _, err := command.Execute(true); err == nil || err.Error() != "must err" {
+		t.Errorf("FailoverCommand.Execute() got = %v, want %v", err, "must err")
+	}
+
+	// 主、备熔断器状态相互独立。
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+	if summary := primary.Summary(); summary.Success != 1 || summary.Failure != 1 {
+		t.Errorf("primary Summary() got = %d/%d, want %d/%d", summary.Success, summary.Failure, 1, 1)
+	}
+	if summary := secondary.Summary(); summary.Success != 1 || summary.Failure != 1 {
+		t.Errorf("secondary Summary() got = %d/%d, want %d/%d", summary.Success, summary.Failure, 1, 1)
+	}
+}
@@ -0,0 +1,109 @@
+package circuit
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+// ErrDuplicateCommand 表示 CommandGroup 中已经存在同名的Command。
+var ErrDuplicateCommand error = errors.New("command: duplicate name")
+
+// CommandGroup 用于按名称统一创建、查找和释放多个Command，可并发使用。
+type CommandGroup struct {
+	lock     sync.RWMutex
+	commands map[string]*Command
+}
+
+// NewCommandGroup 用于新建一个 CommandGroup。
+func NewCommandGroup() *CommandGroup {
+	return &CommandGroup{
+		commands: make(map[string]*Command),
+	}
+}
+
+// GetOrCreate 用于获取指定名称的Command，不存在时通过传入的参数新建一个。
+// 已存在时直接返回，传入的功能函数和选项函数将被忽略。
+// name为空时每次都会新建一个使用生成名称的Command，之后可以通过 Command.Name 获取名称再查找。
+func (group *CommandGroup) GetOrCreate(name string, run CommandFunc, options ...CommandOptionFunc) *Command {
+	if command, ok := group.Get(name); ok {
+		return command
+	}
+
+	group.lock.Lock()
+	defer group.lock.Unlock()
+	if command, ok := group.commands[name]; ok { // 获取写锁期间可能已经被其它goroutine创建了。
+		return command
+	}
+	command := NewCommand(name, run, options...)
+	if existing, ok := group.commands[command.name]; ok { // 生成的名称与已有的Command同名。
+		command.Close()
+		return existing
+	}
+	group.commands[command.name] = command
+	return command
+}
+
+// Add 用于将已经创建的Command加入 CommandGroup，已存在同名的Command时返回 ErrDuplicateCommand。
+func (group *CommandGroup) Add(command *Command) error {
+	group.lock.Lock()
+	defer group.lock.Unlock()
+	if _, ok := group.commands[command.name]; ok {
+		return fmt.Errorf("%s: %w", command.name, ErrDuplicateCommand)
+	}
+	group.commands[command.name] = command
+	return nil
+}
+
+// Get 用于获取指定名称的Command。
+func (group *CommandGroup) Get(name string) (*Command, bool) {
+	group.lock.RLock()
+	defer group.lock.RUnlock()
+	command, ok := group.commands[name]
+	return command, ok
+}
+
+// Summaries 返回所有Command的熔断器状态信息，key为Command名称。
+func (group *CommandGroup) Summaries() map[string]*breaker.BreakerSummary {
+	group.lock.RLock()
+	defer group.lock.RUnlock()
+	summaries := make(map[string]*breaker.BreakerSummary, len(group.commands))
+	for name, command := range group.commands {
+		summaries[name] = command.Summary()
+	}
+	return summaries
+}
+
+// drainAll 返回所有Command的熔断器状态信息，并同时重置上报用的统计数据，key为Command名称。
+func (group *CommandGroup) drainAll() map[string]*breaker.BreakerSummary {
+	group.lock.RLock()
+	defer group.lock.RUnlock()
+	summaries := make(map[string]*breaker.BreakerSummary, len(group.commands))
+	for name, command := range group.commands {
+		summaries[name] = command.DrainStats()
+	}
+	return summaries
+}
+
+// list 返回所有Command，顺序不固定。
+func (group *CommandGroup) list() []*Command {
+	group.lock.RLock()
+	defer group.lock.RUnlock()
+	commands := make([]*Command, 0, len(group.commands))
+	for _, command := range group.commands {
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// CloseAll 用于释放所有Command，并将其从 CommandGroup 中移除。
+func (group *CommandGroup) CloseAll() {
+	group.lock.Lock()
+	defer group.lock.Unlock()
+	for _, command := range group.commands {
+		command.Close()
+	}
+	group.commands = make(map[string]*Command)
+}
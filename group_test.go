@@ -0,0 +1,115 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCommandGroup_GetOrCreate(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return i, nil
+	}
+	group := NewCommandGroup()
+	defer group.CloseAll()
+
+	// 并发获取同一名称，应该得到同一个实例。
+	const goroutineCount = 100
+	commands := make([]*Command, goroutineCount)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutineCount; i++ {
+		wg.Add(1)
+		go func(i int) {
+			commands[i] = group.GetOrCreate("test", run)
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+	for i := 1; i < goroutineCount; i++ {
+		if commands[i] != commands[0] {
+			t.Fatalf("CommandGroup.GetOrCreate() got different instances")
+		}
+	}
+
+	if command, ok := group.Get("test"); !ok || command != commands[0] {
+		t.Errorf("CommandGroup.Get() got = %v, %v, want %v, %v", command, ok, commands[0], true)
+	}
+	if _, ok := group.Get("none"); ok {
+		t.Errorf("CommandGroup.Get() got = %v, want %v", ok, false)
+	}
+
+	group.GetOrCreate("test2", run).Execute(1)
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+	summaries := group.Summaries()
+	if len(summaries) != 2 {
+		t.Errorf("CommandGroup.Summaries() len got = %d, want %d", len(summaries), 2)
+	}
+	if summary := summaries["test2"]; summary == nil || summary.Success != 1 {
+		t.Errorf("CommandGroup.Summaries()[test2] got = %+v, want Success 1", summary)
+	}
+}
+
+// TestCommandGroup_CloseAll 通过goroutine数量判断资源没有泄露，不能与其它测试并行。
+func TestCommandGroup_CloseAll(t *testing.T) {
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return i, nil
+	}
+	group := NewCommandGroup()
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		group.GetOrCreate(fmt.Sprint(i), run)
+	}
+
+	group.CloseAll()
+	time.Sleep(time.Millisecond * 10) // 等待goroutine退出。
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("runtime.NumGoroutine() after CloseAll got = %d, want %d", after, before)
+	}
+	if summaries := group.Summaries(); len(summaries) != 0 {
+		t.Errorf("CommandGroup.Summaries() after CloseAll len got = %d, want %d", len(summaries), 0)
+	}
+}
+
+func TestCommandGroup_Add(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return i, nil
+	}
+	group := NewCommandGroup()
+	defer group.CloseAll()
+
+	command := NewCommand("test", run)
+	if err := group.Add(command); err != nil {
+		t.Fatalf("CommandGroup.Add() got = %v, want %v", err, nil)
+	}
+	if got, ok := group.Get("test"); !ok || got != command {
+		t.Errorf("CommandGroup.Get() got = %v, %v, want %v, %v", got, ok, command, true)
+	}
+
+	// 同名的Command不能重复加入，GetOrCreate也只会返回已有的。
+	duplicate := NewCommand("test", run)
+	defer duplicate.Close()
+	if err := group.Add(duplicate); !errors.Is(err, ErrDuplicateCommand) {
+		t.Errorf("CommandGroup.Add() got = %v, want %v", err, ErrDuplicateCommand)
+	}
+	if got := group.GetOrCreate("test", run); got != command {
+		t.Errorf("CommandGroup.GetOrCreate() got = %v, want %v", got, command)
+	}
+
+	// 名称为空时每次都新建，并以生成的名称保存。
+	unnamed1, unnamed2 := group.GetOrCreate("", run), group.GetOrCreate("", run)
+	if unnamed1 == unnamed2 || unnamed1.Name() == unnamed2.Name() {
+		t.Errorf("CommandGroup.GetOrCreate() with empty name got the same command %s", unnamed1.Name())
+	}
+	for _, unnamed := range []*Command{unnamed1, unnamed2} {
+		if got, ok := group.Get(unnamed.Name()); !ok || got != unnamed {
+			t.Errorf("CommandGroup.Get(%q) got = %v, %v, want %v, %v", unnamed.Name(), got, ok, unnamed, true)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package circuit
+
+import (
+	"time"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+// Logger 是输出日志所需的最小接口，*log.Logger 即满足。
+// 使用slog时可以通过 slog.NewLogLogger 转换。
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// log 用于输出一条Command相关的日志，命令名称以 command=name 的形式附加，便于检索。
+func (command *Command) log(msg string, err error) {
+	if command.logger == nil {
+		return
+	}
+	if err == nil {
+		command.logger.Printf("circuit: %s command=%s", msg, command.name)
+		return
+	}
+	command.logger.Printf("circuit: %s command=%s err=%v", msg, command.name, err)
+}
+
+// watchState 用于向熔断器注册状态变化监听函数，只有第一次调用生效，需要熔断器实现了 breaker.StateNotifier。
+func (command *Command) watchState() {
+	command.watchOnce.Do(func() {
+		command.breaker.(breaker.StateNotifier).NotifyStateChange(command.onStateChange)
+	})
+}
+
+// onStateChange 是注册到熔断器的状态变化监听函数，用于输出状态变化的日志，并发布 EventStateChange 事件。
+func (command *Command) onStateChange(name string, from, to breaker.State) {
+	command.publish(Event{Name: command.name, Type: EventStateChange, Time: time.Now(), State: to})
+	switch to {
+	case breaker.StateOpen:
+		command.log("breaker opened", nil)
+	case breaker.StateHalfOpen: // 切换为半开状态的请求就是第一个尝试请求。
+		command.log("half-open probe admitted", nil)
+	}
+}
+
+// stateForLog 在需要比较状态时返回熔断器当前状态，用于和之后的状态比较，不需要时不读取状态。
+func (command *Command) stateForLog() breaker.State {
+	if !command.pollState() {
+		return breaker.StateClosed
+	}
+	return command.breaker.State()
+}
+
+// logStateChange 用于在熔断器状态发生变化时输出日志，并发布 EventStateChange 事件，before 为 stateForLog 的返回值。
+// 熔断器实现了 breaker.StateNotifier 时，日志与事件由 onStateChange 处理。
+func (command *Command) logStateChange(before breaker.State, pass bool) {
+	if !command.pollState() {
+		return
+	}
+	after := command.breaker.State()
+	if after == before {
+		return
+	}
+	command.publish(Event{Name: command.name, Type: EventStateChange, Time: time.Now(), State: after})
+	switch {
+	case after == breaker.StateOpen:
+		command.log("breaker opened", nil)
+	case after == breaker.StateHalfOpen && pass:
+		command.log("half-open probe admitted", nil)
+	}
+}
+
+// pollState 返回是否需要通过比较执行前后的熔断器状态发现状态变化，设置了日志或订阅了事件，且熔断器不能通知状态变化时才需要。
+func (command *Command) pollState() bool {
+	return !command.stateNotified && (command.logger != nil || command.subscribed())
+}
@@ -0,0 +1,121 @@
+package circuit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+// syncBuffer 是并发安全的 bytes.Buffer，状态变化的日志在熔断器的goroutine中输出。
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitLog 用于等待日志中出现substr，超过1秒仍没有出现时返回false。
+func waitLog(buf *syncBuffer, substr string) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if strings.Contains(buf.String(), substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCommand_logger(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if i.(bool) {
+			return nil, errors.New("must err")
+		}
+		return i, nil
+	}
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		return "fallback", nil
+	}
+	var buf syncBuffer
+	command := NewCommand("test", run,
+		WithCommandFallback(fallback),
+		WithCommandLogger(log.New(&buf, "", 0)))
+	defer command.Close()
+
+	// 成功的执行不输出日志。
+	command.Execute(false)
+	if got := buf.String(); got != "" {
+		t.Errorf("log after success got = %q, want empty", got)
+	}
+
+	// 加上前面的成功共10次执行，达到最小请求数和错误率阈值，下一次执行时熔断开启。
+	for i := 0; i < 9; i++ {
+		command.Execute(true)
+	}
+	if !strings.Contains(buf.String(), "circuit: fallback invoked command=test err=must err") {
+		t.Errorf("log got = %q, want fallback invoked", buf.String())
+	}
+	if strings.Contains(buf.String(), "breaker opened") {
+		t.Errorf("log got = %q, want no breaker opened before trip", buf.String())
+	}
+
+	command.Execute(false)
+	if !waitLog(&buf, "circuit: breaker opened command=test\n") {
+		t.Errorf("log got = %q, want breaker opened", buf.String())
+	}
+	if got := buf.String(); !strings.Contains(got, "circuit: request rejected command=test err=test: open: command: circuit open") {
+		t.Errorf("log got = %q, want request rejected", got)
+	}
+}
+
+// TestCommand_loggerConcurrent 测试并发执行时，每次熔断器状态变化只输出一次日志。
+func TestCommand_loggerConcurrent(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return nil, errors.New("must err")
+	}
+	var buf syncBuffer
+	command := NewCommand("test", run,
+		WithCommandLogger(log.New(&buf, "", 0)),
+		WithCommandBreaker(breaker.NewCutBreaker("test",
+			breaker.WithCutBreakerTimeWindow(5*time.Second),
+			breaker.WithCutBreakerMinRequestThreshold(10),
+			breaker.WithCutBreakerSleepWindow(time.Hour))))
+	defer command.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				command.Execute(nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !waitLog(&buf, "breaker opened") {
+		t.Fatalf("log got = %q, want breaker opened", buf.String())
+	}
+	time.Sleep(50 * time.Millisecond) // 等待可能重复的日志。
+	if got := strings.Count(buf.String(), "breaker opened"); got != 1 {
+		t.Errorf("breaker opened logs got = %d, want %d", got, 1)
+	}
+}
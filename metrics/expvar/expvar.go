@@ -0,0 +1,31 @@
+// Package expvar 提供通过标准库 expvar 发布熔断器状态的方法，无需Prometheus即可在 /debug/vars 中查看。
+package expvar
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+// ErrDuplicateName 表示该名称已经发布过变量。
+var ErrDuplicateName = errors.New("expvar: duplicate name")
+
+// lock 用于保证检查名称与发布变量之间不会被其它 Publish 打断。
+var lock sync.Mutex
+
+// Publish 用于将熔断器的统计摘要以名称name发布为 expvar 变量，每次读取时调用 Summary 并序列化为JSON。
+// expvar 的变量无法取消发布，名称已经存在时（包括其它代码发布的变量）返回包装了 ErrDuplicateName 的错误，而不是panic。
+func Publish(name string, b breaker.Breaker) error {
+	lock.Lock()
+	defer lock.Unlock()
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("%s: %w", name, ErrDuplicateName)
+	}
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return b.Summary()
+	}))
+	return nil
+}
@@ -0,0 +1,37 @@
+package expvar
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/bunnier/circuit/breaker"
+)
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+	b := breaker.NewCutBreaker("foo", breaker.WithCutBreakerTimeWindow(5*time.Second))
+	b.Success()
+	b.Failure()
+
+	if err := Publish("circuit.foo", b); err != nil {
+		t.Fatalf("Publish() got = %v, want nil", err)
+	}
+
+	// 读取时序列化当前的统计摘要。
+	b.Success()
+	var summary breaker.BreakerSummary
+	if err := json.Unmarshal([]byte(expvar.Get("circuit.foo").String()), &summary); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if summary.Name != "foo" || summary.Success != 2 || summary.Failure != 1 {
+		t.Errorf("expvar circuit.foo got = %+v, want Name = foo, Success = 2, Failure = 1", summary)
+	}
+
+	// 重复发布返回错误，不会panic。
+	if err := Publish("circuit.foo", breaker.NewCutBreaker("bar", breaker.WithCutBreakerTimeWindow(5*time.Second))); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("Publish() duplicate got = %v, want %v", err, ErrDuplicateName)
+	}
+}
@@ -0,0 +1,40 @@
+// Package http 提供以HTTP方式查看熔断器状态的处理器。
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/bunnier/circuit"
+	"github.com/bunnier/circuit/breaker"
+)
+
+// Handler 返回一个输出 CommandGroup 中所有Command熔断器状态信息的处理器。
+// 响应为按名称排序的 BreakerSummary JSON数组；通过 ?name=foo 只输出指定名称的Command，不存在时返回404。
+func Handler(group *circuit.CommandGroup) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var summaries []*breaker.BreakerSummary
+		if name := r.URL.Query().Get("name"); name != "" {
+			command, ok := group.Get(name)
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			summaries = []*breaker.BreakerSummary{command.Summary()}
+		} else {
+			summaries = make([]*breaker.BreakerSummary, 0)
+			for _, summary := range group.Summaries() {
+				summaries = append(summaries, summary)
+			}
+			sort.Slice(summaries, func(i, j int) bool {
+				return summaries[i].Name < summaries[j].Name
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(summaries); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
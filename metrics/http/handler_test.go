@@ -0,0 +1,66 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bunnier/circuit"
+	"github.com/bunnier/circuit/breaker"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if i.(bool) {
+			return nil, errors.New("must err")
+		}
+		return "ok", nil
+	}
+	group := circuit.NewCommandGroup()
+	defer group.CloseAll()
+	group.GetOrCreate("foo", run).Execute(false)
+	group.GetOrCreate("bar", run).Execute(true)
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+
+	get := func(url string) (*httptest.ResponseRecorder, []*breaker.BreakerSummary) {
+		recorder := httptest.NewRecorder()
+		Handler(group).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+		var summaries []*breaker.BreakerSummary
+		if recorder.Code == http.StatusOK {
+			if err := json.Unmarshal(recorder.Body.Bytes(), &summaries); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+		}
+		return recorder, summaries
+	}
+
+	// 所有Command，按名称排序。
+	recorder, summaries := get("/")
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type got = %v, want %v", contentType, "application/json")
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("Handler() summaries len got = %d, want %d", len(summaries), 2)
+	}
+	if summaries[0].Name != "bar" || summaries[0].Failure != 1 || summaries[0].Success != 0 {
+		t.Errorf("Handler() summaries[0] got = %+v, want bar with 0/1", summaries[0])
+	}
+	if summaries[1].Name != "foo" || summaries[1].Failure != 0 || summaries[1].Success != 1 {
+		t.Errorf("Handler() summaries[1] got = %+v, want foo with 1/0", summaries[1])
+	}
+
+	// 按名称过滤。
+	if _, summaries := get("/?name=foo"); len(summaries) != 1 || summaries[0].Name != "foo" {
+		t.Errorf("Handler() with name got = %+v, want only foo", summaries)
+	}
+
+	// 不存在的名称。
+	if recorder, _ := get("/?name=none"); recorder.Code != http.StatusNotFound {
+		t.Errorf("Handler() status code got = %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,38 @@
+package circuit
+
+import (
+	"context"
+	"sync"
+)
+
+// MultiCall 描述 MultiExecute 中的一次调用。
+type MultiCall struct {
+	Command *Command    // 执行调用的Command。
+	Param   interface{} // 传给Command的参数。
+}
+
+// MultiResult 是 MultiExecute 中一次调用的结果。
+type MultiResult struct {
+	Result interface{} // Command的返回值。
+	Err    error       // Command返回的错误，熔断开启时为 ErrCircuitOpen。
+}
+
+// MultiExecute 用于并发执行多个相互独立的调用，等待全部完成后按传入的key返回各自的结果。
+// 每个调用都经过各自的Command，单独熔断、降级，一个调用失败不影响其它调用。
+func MultiExecute(ctx context.Context, calls map[string]MultiCall) map[string]MultiResult {
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]MultiResult, len(calls))
+	for key, call := range calls {
+		wg.Add(1)
+		go func(key string, call MultiCall) {
+			defer wg.Done()
+			result, err := call.Command.ContextExecute(ctx, call.Param)
+			lock.Lock()
+			results[key] = MultiResult{result, err}
+			lock.Unlock()
+		}(key, call)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,38 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMultiExecute(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		return i, nil
+	}
+	commands := make(map[string]*Command)
+	for _, name := range []string{"user", "order", "stock"} {
+		commands[name] = NewCommand(name, run)
+		defer commands[name].Close()
+	}
+	commands["stock"].breaker.ForceOpen() // 只有stock熔断开启。
+
+	results := MultiExecute(context.Background(), map[string]MultiCall{
+		"user":  {commands["user"], 1},
+		"order": {commands["order"], 2},
+		"stock": {commands["stock"], 3},
+	})
+
+	if len(results) != 3 {
+		t.Fatalf("MultiExecute() len got = %d, want %d", len(results), 3)
+	}
+	for key, want := range map[string]int{"user": 1, "order": 2} {
+		if res := results[key]; res.Err != nil || res.Result != want {
+			t.Errorf("MultiExecute()[%s] got = %v, %v, want %v, nil", key, res.Result, res.Err, want)
+		}
+	}
+	if res := results["stock"]; !errors.Is(res.Err, ErrCircuitOpen) {
+		t.Errorf("MultiExecute()[stock] got = %v, want %v", res.Err, ErrCircuitOpen)
+	}
+}
@@ -0,0 +1,35 @@
+package circuit
+
+import (
+	"context"
+	"fmt"
+)
+
+// MultiArgCommand 是接收多个参数的Command，执行时通过可变参数传入。
+// 内部依然是单参数的 Command，多个参数打包为 []interface{} 传递，降级函数收到的参数也是该切片。
+// 通过内嵌 Command 的其它方法（如 ExecuteWithInfo）执行时，参数需要自行打包为 []interface{}，否则按功能函数返回错误处理，不会panic。
+type MultiArgCommand struct {
+	*Command
+}
+
+// NewMultiArgCommand 用于新建一个 MultiArgCommand，选项函数与 NewCommand 相同。
+func NewMultiArgCommand(name string, run func(context.Context, ...interface{}) (interface{}, error), options ...CommandOptionFunc) *MultiArgCommand {
+	wrapped := func(ctx context.Context, param interface{}) (interface{}, error) {
+		args, ok := param.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: multi-arg command got param of type %T, want []interface{}", name, param)
+		}
+		return run(ctx, args...)
+	}
+	return &MultiArgCommand{NewCommand(name, wrapped, options...)}
+}
+
+// Execute 用于执行目标函数。
+func (command *MultiArgCommand) Execute(args ...interface{}) (interface{}, error) {
+	return command.Command.Execute(args)
+}
+
+// ContextExecute 用于执行目标函数，ctx将传递给功能函数。
+func (command *MultiArgCommand) ContextExecute(ctx context.Context, args ...interface{}) (interface{}, error) {
+	return command.Command.ContextExecute(ctx, args)
+}
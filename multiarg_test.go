@@ -0,0 +1,65 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMultiArgCommand(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		sum := 0
+		for _, arg := range args {
+			sum += arg.(int)
+		}
+		return sum, nil
+	}
+	command := NewMultiArgCommand("test", run)
+	defer command.Close()
+
+	if res, err := command.Execute(1, 2); err != nil || res != 3 {
+		t.Errorf("MultiArgCommand.Execute() got = %v, %v, want %v, nil", res, err, 3)
+	}
+	if res, err := command.ContextExecute(context.Background(), 1, 2, 3); err != nil || res != 6 {
+		t.Errorf("MultiArgCommand.ContextExecute() got = %v, %v, want %v, nil", res, err, 6)
+	}
+	if summary := command.Summary(); summary.Success != 2 {
+		t.Errorf("MultiArgCommand.Summary() Success got = %d, want %d", summary.Success, 2)
+	}
+}
+
+func TestMultiArgCommand_fallback(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return nil, errors.New("must err")
+	}
+	fallback := func(ctx context.Context, param interface{}, e error) (interface{}, error) {
+		return fmt.Sprint(param.([]interface{})...), nil // 降级函数收到的是参数切片。
+	}
+	command := NewMultiArgCommand("test", run, WithCommandFallback(fallback))
+	defer command.Close()
+
+	if res, err := command.Execute("a", "b", "c"); err != nil || res != "abc" {
+		t.Errorf("MultiArgCommand.Execute() got = %v, %v, want %v, nil", res, err, "abc")
+	}
+}
+
+// TestMultiArgCommand_promoted 测试通过内嵌 Command 的方法传入非切片参数时返回错误，而不是panic。
+func TestMultiArgCommand_promoted(t *testing.T) {
+	t.Parallel()
+	run := func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return len(args), nil
+	}
+	command := NewMultiArgCommand("test", run)
+	defer command.Close()
+
+	if res, err := command.ExecuteWithTimeout(1, time.Second); err == nil {
+		t.Errorf("MultiArgCommand.ExecuteWithTimeout() got = %v, %v, want error", res, err)
+	}
+	if res, _, err := command.ExecuteWithInfo(context.Background(), []interface{}{1, 2}); err != nil || res != 2 {
+		t.Errorf("MultiArgCommand.ExecuteWithInfo() got = %v, %v, want %v, nil", res, err, 2)
+	}
+}
@@ -0,0 +1,37 @@
+package circuit
+
+// outcome 表示功能函数自行指定的执行结果。
+type outcome int
+
+const (
+	outcomeIgnore   outcome = iota + 1 // 熔断器不记录本次执行。
+	outcomeDegraded                    // 降级结果，熔断器记为失败，但不执行降级函数。
+)
+
+// outcomeError 用于包装功能函数返回的错误，以指定熔断器如何记录本次执行结果。
+type outcomeError struct {
+	error
+	outcome outcome
+}
+
+func (e *outcomeError) Unwrap() error {
+	return e.error
+}
+
+// MarkIgnore 用于在功能函数中包装返回的错误，标记本次执行不计入熔断器的统计（如参数校验失败、调用方主动取消等）。
+// 被标记的错误不会执行降级函数，功能函数的返回值和错误将原样返回给调用方，可以通过errors.Is/errors.As判断原始错误。
+func MarkIgnore(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &outcomeError{err, outcomeIgnore}
+}
+
+// MarkDegraded 用于在功能函数中包装返回的错误，标记本次执行得到的是可用的降级结果。
+// 熔断器将记为一次失败，但不会执行降级函数，功能函数的返回值和错误将原样返回给调用方，可以通过errors.Is/errors.As判断原始错误。
+func MarkDegraded(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &outcomeError{err, outcomeDegraded}
+}
@@ -0,0 +1,105 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errValidation = errors.New("validation failed")
+
+func TestCommand_outcome(t *testing.T) {
+	t.Parallel()
+	// 功能函数，按参数标记返回的错误。
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		switch i.(string) {
+		case "ignore":
+			return nil, MarkIgnore(errValidation)
+		case "degraded":
+			return "stale", MarkDegraded(errors.New("partial"))
+		default:
+			return "ok", nil
+		}
+	}
+	// 降级函数。
+	fallbackCalled := false
+	fallback := func(ctx context.Context, i interface{}, e error) (interface{}, error) {
+		fallbackCalled = true
+		return "fallback", nil
+	}
+	command := NewCommand("test", run, WithCommandFallback(fallback))
+	defer command.Close()
+
+	// 标记为忽略的错误不计入熔断器，多次出现也不会熔断。
+	for i := 0; i < 100; i++ {
+		if _, err := command.Execute("ignore"); !errors.Is(err, errValidation) {
+			t.Fatalf("Command.Execute() got = %v, want %v", err, errValidation)
+		}
+	}
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+	if summary := command.Summary(); summary.Total != 0 || summary.Status != "closed" {
+		t.Errorf("Command.Summary() got = %d/%s, want %d/%s", summary.Total, summary.Status, 0, "closed")
+	}
+
+	// 标记为降级结果的错误计为失败，但原样返回，不执行降级函数。
+	if res, err := command.Execute("degraded"); res != "stale" || err == nil || err.Error() != "partial" {
+		t.Errorf("Command.Execute() got = %v, %v, want %v, %v", res, err, "stale", "partial")
+	}
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+	if summary := command.Summary(); summary.Failure != 1 {
+		t.Errorf("Command.Summary() Failure got = %d, want %d", summary.Failure, 1)
+	}
+	if fallbackCalled {
+		t.Errorf("fallback called, want not")
+	}
+}
+
+func TestMarkOutcome_nil(t *testing.T) {
+	t.Parallel()
+	if err := MarkIgnore(nil); err != nil {
+		t.Errorf("MarkIgnore(nil) got = %v, want nil", err)
+	}
+	if err := MarkDegraded(nil); err != nil {
+		t.Errorf("MarkDegraded(nil) got = %v, want nil", err)
+	}
+}
+
+// validationError 是测试用的错误类型，不应计为失败。
+type validationError struct{}
+
+func (validationError) Error() string { return "invalid param" }
+
+func TestCommand_failureFilter(t *testing.T) {
+	t.Parallel()
+	// 功能函数，参数为false时返回validationError。
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if !i.(bool) {
+			return nil, validationError{}
+		}
+		return nil, errors.New("must err")
+	}
+	filter := func(err error) bool {
+		var ve validationError
+		return !errors.As(err, &ve)
+	}
+	command := NewCommand("test", run, WithCommandFailureFilter(filter))
+	defer command.Close()
+
+	for i := 0; i < 100; i++ {
+		if _, err := command.Execute(false); !errors.As(err, &validationError{}) {
+			t.Fatalf("Command.Execute() got = %v, want %v", err, validationError{})
+		}
+	}
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+	if summary := command.Summary(); summary.Success != 100 || summary.Status != "closed" {
+		t.Errorf("Command.Summary() got = %d/%s, want %d/%s", summary.Success, summary.Status, 100, "closed")
+	}
+
+	// 其它错误依然计为失败。
+	command.Execute(true)
+	time.Sleep(time.Millisecond * 10) // 休息10ms，以确保数据能记录完成。
+	if summary := command.Summary(); summary.Failure != 1 {
+		t.Errorf("Command.Summary() Failure got = %d, want %d", summary.Failure, 1)
+	}
+}
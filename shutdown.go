@@ -0,0 +1,56 @@
+package circuit
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// defaultShutdownWait 是 InstallShutdownHook 等待正在执行的Command完成的最长时间。
+const defaultShutdownWait = 10 * time.Second
+
+// InstallShutdownHook 用于安装一个优雅退出的钩子，等待正在执行的Command完成的最长时间为10秒，见 InstallShutdownHookWithTimeout。
+func InstallShutdownHook(ctx context.Context, group *CommandGroup, logger Logger) <-chan struct{} {
+	return InstallShutdownHookWithTimeout(ctx, group, logger, defaultShutdownWait)
+}
+
+// InstallShutdownHookWithTimeout 用于安装一个优雅退出的钩子：ctx取消时，通过 Command.Drain 让group中所有Command停止接收新的执行，
+// 最多等待timeout让正在执行的完成，再通过logger按名称顺序输出最终统计信息，然后释放所有Command。
+// 超过timeout仍未完成的执行不再等待，输出日志后照常释放，其结果不会计入最终统计信息。
+// 不处理信号，调用方可以通过 signal.NotifyContext 等方式把信号转成ctx的取消。
+// 返回的channel在钩子执行完成后关闭。
+func InstallShutdownHookWithTimeout(ctx context.Context, group *CommandGroup, logger Logger, timeout time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+
+		// 先让所有Command停止接收新的执行，再一起等待，总的等待时间不超过timeout。
+		commands := group.list()
+		for _, command := range commands {
+			command.Drain()
+		}
+		waitCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		for _, command := range commands {
+			if err := command.Wait(waitCtx); err != nil {
+				logger.Printf("circuit: %s shutdown wait: %v", command.name, err)
+			}
+		}
+
+		summaries := group.drainAll()
+		names := make([]string, 0, len(summaries))
+		for name := range summaries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			s := summaries[name]
+			logger.Printf("circuit: %s final summary: success=%d failure=%d timeout=%d fallbackSuccess=%d fallbackFailure=%d errorPercentage=%.2f status=%s",
+				name, s.Success, s.Failure, s.Timeout, s.FallbackSuccess, s.FallbackFailure, s.ErrorPercentage, s.Status)
+		}
+
+		group.CloseAll()
+	}()
+	return done
+}
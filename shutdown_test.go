@@ -0,0 +1,132 @@
+package circuit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstallShutdownHook(t *testing.T) {
+	t.Parallel()
+	startedCh := make(chan struct{})
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		if releaseCh, ok := i.(chan struct{}); ok { // 挂起到releaseCh关闭。
+			startedCh <- struct{}{}
+			<-releaseCh
+			return "ok", nil
+		}
+		if i.(bool) {
+			return nil, errors.New("must err")
+		}
+		return i, nil
+	}
+	group := NewCommandGroup()
+	user := group.GetOrCreate("user", run)
+	order := group.GetOrCreate("order", run)
+	user.Execute(false)
+	order.Execute(true)
+
+	// 退出时还在执行的请求。
+	releaseCh := make(chan struct{})
+	inFlightDone := make(chan error, 1)
+	go func() {
+		_, err := user.Execute(releaseCh)
+		inFlightDone <- err
+	}()
+	<-startedCh
+
+	var buf bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := InstallShutdownHook(ctx, group, log.New(&buf, "", 0))
+
+	select {
+	case <-done:
+		t.Fatalf("InstallShutdownHook() done before cancel")
+	case <-time.After(time.Millisecond * 10):
+	}
+
+	cancel()
+
+	// 等待正在执行的请求完成，期间不再接收新的执行。
+	select {
+	case <-done:
+		t.Fatalf("InstallShutdownHook() done before in-flight call finished")
+	case <-time.After(time.Millisecond * 20):
+	}
+	if _, err := order.Execute(false); !errors.Is(err, ErrClosed) {
+		t.Errorf("Command.Execute() during shutdown got = %v, want %v", err, ErrClosed)
+	}
+
+	close(releaseCh)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("InstallShutdownHook() not done after cancel")
+	}
+	if err := <-inFlightDone; err != nil {
+		t.Errorf("in-flight Command.Execute() got = %v, want nil", err)
+	}
+
+	// 按名称顺序输出。
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("InstallShutdownHook() logged %d lines, want %d: %q", len(lines), 2, buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "circuit: order final summary: success=0 failure=1") {
+		t.Errorf("InstallShutdownHook() line 0 got = %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "circuit: user final summary: success=2 failure=0") { // 包含退出时还在执行的请求。
+		t.Errorf("InstallShutdownHook() line 1 got = %q", lines[1])
+	}
+
+	// 所有Command都已经释放。
+	for _, command := range []*Command{user, order} {
+		select {
+		case <-command.Done():
+		default:
+			t.Errorf("Command %s not closed", command.name)
+		}
+	}
+	if summaries := group.Summaries(); len(summaries) != 0 {
+		t.Errorf("CommandGroup.Summaries() len got = %d, want %d", len(summaries), 0)
+	}
+}
+
+// TestInstallShutdownHookWithTimeout 测试正在执行的请求超过等待时间仍未完成时，不再等待，照常释放。
+func TestInstallShutdownHookWithTimeout(t *testing.T) {
+	t.Parallel()
+	startedCh := make(chan struct{})
+	releaseCh := make(chan struct{})
+	defer close(releaseCh)
+	run := func(ctx context.Context, i interface{}) (interface{}, error) {
+		startedCh <- struct{}{}
+		<-releaseCh
+		return "ok", nil
+	}
+	group := NewCommandGroup()
+	user := group.GetOrCreate("user", run)
+	go user.Execute(nil)
+	<-startedCh
+
+	var buf bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	select {
+	case <-InstallShutdownHookWithTimeout(ctx, group, log.New(&buf, "", 0), time.Millisecond*20):
+	case <-time.After(time.Second):
+		t.Fatalf("InstallShutdownHookWithTimeout() not done after timeout")
+	}
+	if got := buf.String(); !strings.Contains(got, "circuit: user shutdown wait: context deadline exceeded") ||
+		!strings.Contains(got, "circuit: user final summary: success=0") {
+		t.Errorf("InstallShutdownHookWithTimeout() log got = %q", got)
+	}
+	select {
+	case <-user.Done():
+	default:
+		t.Errorf("Command %s not closed", user.name)
+	}
+}